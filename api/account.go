@@ -1,40 +1,39 @@
 package api
 
 import (
-	"database/sql"
+	"errors"
 	"github.com/gin-gonic/gin"
-	"github.com/lib/pq"
 	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"github.com/max-rodziyevsky/go-simple-bank/token"
 	"net/http"
+	"strconv"
 )
 
+var errAccountNotOwned = errors.New("account doesn't belong to the authenticated user")
+
+// accountURI binds the account id of nested account routes, like /accounts/:id/transfers
+type accountURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
 type createAccountRequest struct {
-	Owner    string `json:"owner" binding:"required"`
 	Currency string `json:"currency" binding:"required,currency"`
 }
 
 func (s *Server) createAccount(ctx *gin.Context) {
 	var req createAccountRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
 		return
 	}
 
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 	account, err := s.store.CreateAccount(ctx, repo.CreateAccountParams{
-		Owner:    req.Owner,
+		Owner:    authPayload.Username,
 		Currency: req.Currency,
 	})
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			//log.Println(pqErr.Code.Name())
-			// foreign_key_violation unique_violation
-			switch pqErr.Code.Name() {
-			case repo.ForeignKeyViolation, repo.UniqueViolation:
-				ctx.JSON(http.StatusForbidden, errorResponse(err))
-				return
-			}
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		handleError(ctx, err)
 		return
 	}
 
@@ -48,73 +47,122 @@ type getAccountRequest struct {
 func (s *Server) getAccount(ctx *gin.Context) {
 	var req getAccountRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
 		return
 	}
 
-	account, err := s.store.GetAccount(ctx, req.ID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	account, ok := s.ownedAccount(ctx, req.ID)
+	if !ok {
 		return
 	}
 
 	ctx.JSON(http.StatusOK, account)
 }
 
+const (
+	sortByID        = "id"
+	sortByCreatedAt = "created_at"
+	sortByBalance   = "balance"
+)
+
+// listAccountsRequest pages through the accounts with a keyset: after_id is the id of the last account
+// of the previous page, which is returned as next_cursor
 type listAccountsRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	AfterID int64  `form:"after_id" binding:"min=0"`
+	Limit   int32  `form:"limit" binding:"omitempty,min=1,max=100"`
+	Sort    string `form:"sort" binding:"omitempty,oneof=id created_at balance"`
 }
 
 func (s *Server) listAccounts(ctx *gin.Context) {
 	var req listAccountsRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
 		return
 	}
 
+	if req.Sort == "" {
+		req.Sort = sortByID
+	}
+	limit := pageSizeOrDefault(req.Limit)
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 	arg := repo.ListAccountsParams{
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize, //
+		Owner:    authPayload.Username,
+		AfterID:  req.AfterID,
+		Sort:     req.Sort,
+		PageSize: limit + 1,
 	}
 
 	accounts, err := s.store.ListAccounts(ctx, arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		handleError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, accounts)
+	result := page[repo.Account]{Items: accounts}
+	if len(accounts) > int(limit) {
+		result.Items = accounts[:limit]
+		result.NextCursor = strconv.FormatInt(result.Items[limit-1].ID, 10)
+	}
+
+	ctx.JSON(http.StatusOK, result)
 }
 
+// updateAccountRequest sets the balance of any account, it's an admin correction.
+// Balance is a pointer, so a zero balance can be told apart from a missing one.
 type updateAccountRequest struct {
-	ID      int64 `json:"id" binding:"required,min=1"`
-	Balance int64 `json:"balance" binding:"required,min=0"`
+	ID      int64  `json:"id" binding:"required,min=1"`
+	Balance *int64 `json:"balance" binding:"required"`
 }
 
 func (s *Server) updateAccount(ctx *gin.Context) {
 	var req updateAccountRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
 		return
 	}
 
-	arg := repo.UpdateAccountParams{
-		ID:      req.ID,
-		Balance: req.Balance,
+	arg := repo.AdjustBalanceTxParams{
+		AccountID: req.ID,
+		Balance:   *req.Balance,
 	}
 
-	account, err := s.store.UpdateAccount(ctx, arg)
+	result, err := s.store.AdjustBalanceTx(ctx, arg)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// overdraftLimitRequest sets how far below zero the balance of an account may go.
+// OverdraftLimit is a pointer, so a zero limit can be told apart from a missing one.
+type overdraftLimitRequest struct {
+	OverdraftLimit *int64 `json:"overdraft_limit" binding:"required,min=0"`
+}
+
+// updateOverdraftLimit sets the overdraft limit of any account, it's an admin decision.
+// A limit which the current balance is already below is refused with insufficient funds.
+func (s *Server) updateOverdraftLimit(ctx *gin.Context) {
+	var uri accountURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	var req overdraftLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	account, err := s.store.UpdateAccountOverdraftLimit(ctx, repo.UpdateAccountOverdraftLimitParams{
+		OverdraftLimit: *req.OverdraftLimit,
+		ID:             uri.ID,
+	})
+	if err != nil {
+		handleError(ctx, err)
 		return
 	}
 
@@ -125,18 +173,85 @@ type deleteAccountRequest struct {
 	ID int64 `uri:"id" binding:"required,min=1"`
 }
 
+// deleteAccount closes the account, it's kept with its entries and transfers for the history
 func (s *Server) deleteAccount(ctx *gin.Context) {
 	var req deleteAccountRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
 		return
 	}
 
-	err := s.store.DeleteAccount(ctx, req.ID)
+	if _, ok := s.ownedAccount(ctx, req.ID); !ok {
+		return
+	}
+
+	_, err := s.store.ChangeAccountStatusTx(ctx, repo.ChangeAccountStatusTxParams{
+		AccountID: req.ID,
+		Status:    repo.AccountStatusClosed,
+	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		handleError(ctx, err)
 		return
 	}
 
 	ctx.JSON(http.StatusNoContent, nil)
 }
+
+// freezeAccount is a compliance action, the route is for admins only and works on any account,
+// so the owner can't lift a freeze by unfreezing the account
+func (s *Server) freezeAccount(ctx *gin.Context) {
+	s.changeAccountStatus(ctx, repo.AccountStatusFrozen, false)
+}
+
+func (s *Server) unfreezeAccount(ctx *gin.Context) {
+	s.changeAccountStatus(ctx, repo.AccountStatusActive, false)
+}
+
+func (s *Server) closeAccount(ctx *gin.Context) {
+	s.changeAccountStatus(ctx, repo.AccountStatusClosed, true)
+}
+
+// changeAccountStatus moves the account to the status, the store refuses transitions which aren't
+// allowed from the current status. With ownerOnly the account must belong to the authenticated user.
+func (s *Server) changeAccountStatus(ctx *gin.Context, status string, ownerOnly bool) {
+	var uri accountURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	if ownerOnly {
+		if _, ok := s.ownedAccount(ctx, uri.ID); !ok {
+			return
+		}
+	}
+
+	account, err := s.store.ChangeAccountStatusTx(ctx, repo.ChangeAccountStatusTxParams{
+		AccountID: uri.ID,
+		Status:    status,
+	})
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
+
+// ownedAccount loads the account and makes sure it belongs to the authenticated user.
+// It writes the error response itself, so callers only have to return when it's not ok.
+func (s *Server) ownedAccount(ctx *gin.Context, id int64) (repo.Account, bool) {
+	account, err := s.store.GetAccount(ctx, id)
+	if err != nil {
+		handleError(ctx, err)
+		return account, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username {
+		ctx.JSON(http.StatusForbidden, errorResponse(codeForbidden, errAccountNotOwned))
+		return account, false
+	}
+
+	return account, true
+}
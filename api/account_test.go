@@ -9,27 +9,34 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
 	mockrepo "github.com/max-rodziyevsky/go-simple-bank/internal/repo/mock"
+	"github.com/max-rodziyevsky/go-simple-bank/token"
 	"github.com/max-rodziyevsky/go-simple-bank/util"
 	"github.com/stretchr/testify/require"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 // TestGetAccountAPI
 func TestGetAccountAPI(t *testing.T) {
-	account := randomAccount()
+	user, _ := createRandomUser(t)
+	account := randomAccount(user.Username)
 
 	testCases := []struct {
 		name          string
 		accountID     int64
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(mockStore *mockrepo.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name:      "OK",
 			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
@@ -41,14 +48,47 @@ func TestGetAccountAPI(t *testing.T) {
 				requireBodyMatchAccount(t, recorder.Body, account)
 			},
 		},
+		{
+			name:      "NotOwner",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
 		{
 			name:      "NotFound",
 			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
 					Times(1).
-					Return(repo.Account{}, sql.ErrNoRows)
+					Return(repo.Account{}, repo.ErrRecordNotFound)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusNotFound, recorder.Code)
@@ -57,6 +97,9 @@ func TestGetAccountAPI(t *testing.T) {
 		{
 			name:      "InternalServerError",
 			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
@@ -70,6 +113,9 @@ func TestGetAccountAPI(t *testing.T) {
 		{
 			name:      "InvalidID",
 			accountID: 0,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().
 					GetAccount(gomock.Any(), gomock.Any()).
@@ -90,14 +136,16 @@ func TestGetAccountAPI(t *testing.T) {
 
 			mockStore := mockrepo.NewMockStore(ctrl)
 			tc.buildStubs(mockStore)
+			stubTokenNotRevoked(mockStore)
 
-			server := NewServer(mockStore)
+			server := newTestServer(t, mockStore)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprintf("/accounts/%d", tc.accountID)
 			request, err := http.NewRequest(http.MethodGet, url, nil)
 			require.NoError(t, err)
 
+			tc.setupAuth(t, request, server.tokenMaker)
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(t, recorder)
 		})
@@ -106,20 +154,24 @@ func TestGetAccountAPI(t *testing.T) {
 }
 
 func TestCreateAccount(t *testing.T) {
-	account := randomAccount()
+	user, _ := createRandomUser(t)
+	account := randomAccount(user.Username)
 
 	testCases := []struct {
 		name          string
 		arg           gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(mockStore *mockrepo.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name: "OK",
 			arg: gin.H{
-				"owner":    account.Owner,
 				"currency": account.Currency,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				arg := repo.CreateAccountParams{
 					Owner:    account.Owner,
@@ -137,26 +189,70 @@ func TestCreateAccount(t *testing.T) {
 			},
 		},
 		{
-			name: "InvalidOwner",
+			name: "OwnerFromToken",
+			arg: gin.H{
+				"owner":    "someone_else",
+				"currency": account.Currency,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				arg := repo.CreateAccountParams{
+					Owner:    user.Username,
+					Currency: account.Currency,
+				}
+
+				mockStore.EXPECT().
+					CreateAccount(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
 			arg: gin.H{
-				"owner":    "",
 				"currency": account.Currency,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().
 					CreateAccount(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
 		{
 			name: "InvalidCurrency",
 			arg: gin.H{
-				"owner":    account.Owner,
 				"currency": "",
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().
+					CreateAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "DisabledCurrency",
+			arg: gin.H{
+				"currency": disabledCurrency,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().
 					CreateAccount(gomock.Any(), gomock.Any()).
@@ -169,9 +265,11 @@ func TestCreateAccount(t *testing.T) {
 		{
 			name: "InternalError",
 			arg: gin.H{
-				"owner":    account.Owner,
 				"currency": account.Currency,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().
 					CreateAccount(gomock.Any(), gomock.Any()).
@@ -193,8 +291,9 @@ func TestCreateAccount(t *testing.T) {
 
 			mockStore := mockrepo.NewMockStore(ctrl)
 			tc.buildStubs(mockStore)
+			stubTokenNotRevoked(mockStore)
 
-			server := NewServer(mockStore)
+			server := newTestServer(t, mockStore)
 			recorder := httptest.NewRecorder()
 
 			data, err := json.Marshal(tc.arg)
@@ -204,6 +303,7 @@ func TestCreateAccount(t *testing.T) {
 			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
 			require.NoError(t, err)
 
+			tc.setupAuth(t, request, server.tokenMaker)
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(t, recorder)
 		})
@@ -211,50 +311,114 @@ func TestCreateAccount(t *testing.T) {
 }
 
 func TestListAccounts(t *testing.T) {
+	user, _ := createRandomUser(t)
+
 	n := 5
-	accounts := make([]repo.Account, n)
-	for i := 0; i < n; i++ {
-		accounts[i] = randomAccount()
+	accounts := make([]repo.Account, n+1)
+	for i := range accounts {
+		accounts[i] = randomAccount(user.Username)
 	}
 
 	type Query struct {
-		PageID   int32
-		PageSize int32
+		AfterID int64
+		Limit   int32
+		Sort    string
+	}
+
+	authorize := func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+		addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 	}
 
 	testCases := []struct {
 		name          string
 		query         Query
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(mockStore *mockrepo.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
-			name: "OK",
-			query: Query{
-				PageID:   1,
-				PageSize: int32(n),
-			},
+			name:      "OK",
+			query:     Query{Limit: int32(n)},
+			setupAuth: authorize,
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				arg := repo.ListAccountsParams{
-					Offset: 0,
-					Limit:  int32(n),
+					Owner:    user.Username,
+					Sort:     sortByID,
+					PageSize: int32(n) + 1,
 				}
 
 				mockStore.EXPECT().
 					ListAccounts(gomock.Any(), gomock.Eq(arg)).
 					Times(1).
+					Return(accounts[:n], nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccounts(t, recorder.Body, accounts[:n], "")
+			},
+		},
+		{
+			name:      "NextPage",
+			query:     Query{Limit: int32(n)},
+			setupAuth: authorize,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(1).
 					Return(accounts, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				requireBodyMatchAccounts(t, recorder.Body, accounts)
+				requireBodyMatchAccounts(t, recorder.Body, accounts[:n], fmt.Sprint(accounts[n-1].ID))
 			},
 		},
 		{
-			name: "InvalidPageID",
-			query: Query{
-				PageID:   0,
-				PageSize: int32(n),
+			name:      "AfterIDSortedByBalance",
+			query:     Query{AfterID: accounts[0].ID, Sort: sortByBalance},
+			setupAuth: authorize,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				arg := repo.ListAccountsParams{
+					Owner:    user.Username,
+					AfterID:  accounts[0].ID,
+					Sort:     sortByBalance,
+					PageSize: defaultPageSize + 1,
+				}
+
+				mockStore.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(accounts[1:], nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccounts(t, recorder.Body, accounts[1:], "")
+			},
+		},
+		{
+			name:      "SortedByCreatedAt",
+			query:     Query{Sort: sortByCreatedAt},
+			setupAuth: authorize,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				arg := repo.ListAccountsParams{
+					Owner:    user.Username,
+					Sort:     sortByCreatedAt,
+					PageSize: defaultPageSize + 1,
+				}
+
+				mockStore.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]repo.Account{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccounts(t, recorder.Body, []repo.Account{}, "")
+			},
+		},
+		{
+			name:  "NoAuthorization",
+			query: Query{Limit: int32(n)},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().
@@ -262,15 +426,26 @@ func TestListAccounts(t *testing.T) {
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
 		{
-			name: "InvalidPageSize",
-			query: Query{
-				PageID:   1,
-				PageSize: 0,
+			name:      "InvalidAfterID",
+			query:     Query{AfterID: -1},
+			setupAuth: authorize,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
+		},
+		{
+			name:      "InvalidLimit",
+			query:     Query{Limit: maxPageSize + 1},
+			setupAuth: authorize,
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().
 					ListAccounts(gomock.Any(), gomock.Any()).
@@ -281,11 +456,22 @@ func TestListAccounts(t *testing.T) {
 			},
 		},
 		{
-			name: "InternalServerError",
-			query: Query{
-				PageID:   1,
-				PageSize: int32(n),
+			name:      "InvalidSort",
+			query:     Query{Sort: "owner"},
+			setupAuth: authorize,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
+		},
+		{
+			name:      "InternalServerError",
+			query:     Query{Limit: int32(n)},
+			setupAuth: authorize,
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().
 					ListAccounts(gomock.Any(), gomock.Any()).
@@ -307,8 +493,9 @@ func TestListAccounts(t *testing.T) {
 
 			mockStore := mockrepo.NewMockStore(ctrl)
 			tc.buildStubs(mockStore)
+			stubTokenNotRevoked(mockStore)
 
-			server := NewServer(mockStore)
+			server := newTestServer(t, mockStore)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprint("/accounts")
@@ -316,10 +503,18 @@ func TestListAccounts(t *testing.T) {
 			require.NoError(t, err)
 
 			q := request.URL.Query()
-			q.Add("page_id", fmt.Sprint(tc.query.PageID))
-			q.Add("page_size", fmt.Sprint(tc.query.PageSize))
+			if tc.query.AfterID != 0 {
+				q.Add("after_id", fmt.Sprint(tc.query.AfterID))
+			}
+			if tc.query.Limit != 0 {
+				q.Add("limit", fmt.Sprint(tc.query.Limit))
+			}
+			if tc.query.Sort != "" {
+				q.Add("sort", tc.query.Sort)
+			}
 			request.URL.RawQuery = q.Encode()
 
+			tc.setupAuth(t, request, server.tokenMaker)
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(t, recorder)
 		})
@@ -327,52 +522,96 @@ func TestListAccounts(t *testing.T) {
 }
 
 func TestUpdateAccount(t *testing.T) {
-	account := randomAccount()
-	newBalance := util.RandomMoney()
+	user, _ := createRandomUser(t)
+	account := randomAccount(user.Username)
+	newBalance := account.Balance + util.RandomInt(1, 1000)
 
-	updatedAccount := account
-	updatedAccount.Balance = newBalance
+	result := randomBalanceChange(account, newBalance-account.Balance, repo.EntryKindAdjustment)
 
-	type req updateAccountRequest
+	asAdmin := func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+		addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "admin", util.AdminRole, time.Minute)
+	}
 
 	testCases := []struct {
 		name          string
-		req           req
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(mockStore *mockrepo.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name: "OK",
-			req: req{
-				ID:      account.ID,
-				Balance: newBalance,
+			body: gin.H{
+				"id":      account.ID,
+				"balance": newBalance,
 			},
+			setupAuth: asAdmin,
 			buildStubs: func(mockStore *mockrepo.MockStore) {
-				arg := repo.UpdateAccountParams{
-					ID:      account.ID,
-					Balance: newBalance,
+				arg := repo.AdjustBalanceTxParams{
+					AccountID: account.ID,
+					Balance:   newBalance,
 				}
 				mockStore.EXPECT().
-					UpdateAccount(gomock.Any(), gomock.Eq(arg)).
+					AdjustBalanceTx(gomock.Any(), gomock.Eq(arg)).
 					Times(1).
-					Return(updatedAccount, nil)
+					Return(result, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				requireBodyMatchAccountUpdate(t, recorder.Body, account)
+				requireBodyMatchBalanceChange(t, recorder.Body, result)
+			},
+		},
+		{
+			name: "ZeroBalance",
+			body: gin.H{
+				"id":      account.ID,
+				"balance": 0,
+			},
+			setupAuth: asAdmin,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				arg := repo.AdjustBalanceTxParams{
+					AccountID: account.ID,
+					Balance:   0,
+				}
+				mockStore.EXPECT().
+					AdjustBalanceTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(repo.BalanceChangeTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NotAdmin",
+			body: gin.H{
+				"id":      account.ID,
+				"balance": newBalance,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().
+					AdjustBalanceTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
 			},
 		},
 		{
 			name: "InternalServerError",
-			req: req{
-				ID:      account.ID,
-				Balance: newBalance,
+			body: gin.H{
+				"id":      account.ID,
+				"balance": newBalance,
 			},
+			setupAuth: asAdmin,
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().
-					UpdateAccount(gomock.Any(), gomock.Any()).
+					AdjustBalanceTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(repo.Account{}, sql.ErrConnDone)
+					Return(repo.BalanceChangeTxResult{}, sql.ErrConnDone)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
@@ -380,15 +619,16 @@ func TestUpdateAccount(t *testing.T) {
 		},
 		{
 			name: "NotFound",
-			req: req{
-				ID:      account.ID,
-				Balance: newBalance,
+			body: gin.H{
+				"id":      account.ID,
+				"balance": newBalance,
 			},
+			setupAuth: asAdmin,
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().
-					UpdateAccount(gomock.Any(), gomock.Any()).
+					AdjustBalanceTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(repo.Account{}, sql.ErrNoRows)
+					Return(repo.BalanceChangeTxResult{}, repo.ErrRecordNotFound)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusNotFound, recorder.Code)
@@ -396,13 +636,14 @@ func TestUpdateAccount(t *testing.T) {
 		},
 		{
 			name: "InvalidID",
-			req: req{
-				ID:      0,
-				Balance: newBalance,
+			body: gin.H{
+				"id":      0,
+				"balance": newBalance,
 			},
+			setupAuth: asAdmin,
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().
-					UpdateAccount(gomock.Any(), gomock.Any()).
+					AdjustBalanceTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -410,14 +651,14 @@ func TestUpdateAccount(t *testing.T) {
 			},
 		},
 		{
-			name: "InvalidBalance",
-			req: req{
-				ID:      account.ID,
-				Balance: -1,
+			name: "MissingBalance",
+			body: gin.H{
+				"id": account.ID,
 			},
+			setupAuth: asAdmin,
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().
-					UpdateAccount(gomock.Any(), gomock.Any()).
+					AdjustBalanceTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -435,47 +676,246 @@ func TestUpdateAccount(t *testing.T) {
 
 			mockStore := mockrepo.NewMockStore(ctrl)
 			tc.buildStubs(mockStore)
+			stubTokenNotRevoked(mockStore)
 
-			server := NewServer(mockStore)
+			server := newTestServer(t, mockStore)
 			recorder := httptest.NewRecorder()
 
-			data, err := json.Marshal(tc.req)
+			data, err := json.Marshal(tc.body)
 			require.NoError(t, err)
 
 			url := fmt.Sprint("/accounts")
 			request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestUpdateOverdraftLimit(t *testing.T) {
+	user, _ := createRandomUser(t)
+	account := randomAccount(user.Username)
+	limit := util.RandomMoney()
+
+	updated := account
+	updated.OverdraftLimit = limit
+
+	asAdmin := func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+		addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "admin", util.AdminRole, time.Minute)
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(mockStore *mockrepo.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			body:      gin.H{"overdraft_limit": limit},
+			setupAuth: asAdmin,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				arg := repo.UpdateAccountOverdraftLimitParams{
+					OverdraftLimit: limit,
+					ID:             account.ID,
+				}
+				mockStore.EXPECT().
+					UpdateAccountOverdraftLimit(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(updated, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, updated)
+			},
+		},
+		{
+			name:      "ZeroLimit",
+			body:      gin.H{"overdraft_limit": 0},
+			setupAuth: asAdmin,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				arg := repo.UpdateAccountOverdraftLimitParams{
+					OverdraftLimit: 0,
+					ID:             account.ID,
+				}
+				mockStore.EXPECT().
+					UpdateAccountOverdraftLimit(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NotAdmin",
+			body: gin.H{"overdraft_limit": limit},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().
+					UpdateAccountOverdraftLimit(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "BalanceBelowLimit",
+			body:      gin.H{"overdraft_limit": 0},
+			setupAuth: asAdmin,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().
+					UpdateAccountOverdraftLimit(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(repo.Account{}, repo.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:      "NotFound",
+			body:      gin.H{"overdraft_limit": limit},
+			setupAuth: asAdmin,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().
+					UpdateAccountOverdraftLimit(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(repo.Account{}, repo.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "NegativeLimit",
+			body:      gin.H{"overdraft_limit": -1},
+			setupAuth: asAdmin,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().
+					UpdateAccountOverdraftLimit(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "MissingLimit",
+			body:      gin.H{},
+			setupAuth: asAdmin,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().
+					UpdateAccountOverdraftLimit(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mockrepo.NewMockStore(ctrl)
+			tc.buildStubs(mockStore)
+			stubTokenNotRevoked(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d/overdraft_limit", account.ID)
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			require.NoError(t, err)
 
+			tc.setupAuth(t, request, server.tokenMaker)
 			server.router.ServeHTTP(recorder, request)
-			requireBodyMatchAccountUpdate(t, recorder.Body, account)
+			tc.checkResponse(t, recorder)
 		})
 	}
 }
 
 func TestDeleteAccount(t *testing.T) {
-	account := randomAccount()
+	user, _ := createRandomUser(t)
+	account := randomAccount(user.Username)
+	closeArg := repo.ChangeAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    repo.AccountStatusClosed,
+	}
 
 	testCases := []struct {
 		name       string
 		accountID  int64
+		setupAuth  func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs func(mockStore *mockrepo.MockStore)
 		statusCode int
 	}{
 		{
-			name:      "Deleted",
+			name:      "Closed",
 			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
 				mockStore.EXPECT().
-					DeleteAccount(gomock.Any(), gomock.Eq(account.ID)).
-					Times(1)
+					ChangeAccountStatusTx(gomock.Any(), gomock.Eq(closeArg)).
+					Times(1).
+					Return(repo.Account{}, nil)
 			},
 			statusCode: http.StatusNoContent,
 		},
+		{
+			name:      "NotOwner",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				mockStore.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:      "NoAuthorization",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				mockStore.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			statusCode: http.StatusUnauthorized,
+		},
 		{
 			name:      "InvalidID",
 			accountID: 0,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().
-					DeleteAccount(gomock.Any(), gomock.Any()).
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			statusCode: http.StatusBadRequest,
@@ -483,14 +923,33 @@ func TestDeleteAccount(t *testing.T) {
 		{
 			name:      "InternalServerError",
 			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
 				mockStore.EXPECT().
-					DeleteAccount(gomock.Any(), gomock.Any()).
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(sql.ErrConnDone)
+					Return(repo.Account{}, sql.ErrConnDone)
 			},
 			statusCode: http.StatusInternalServerError,
 		},
+		{
+			name:      "BalanceNotZero",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				mockStore.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Eq(closeArg)).
+					Times(1).
+					Return(repo.Account{}, repo.ErrBalanceNotZero)
+			},
+			statusCode: http.StatusConflict,
+		},
 	}
 
 	for i := range testCases {
@@ -502,26 +961,175 @@ func TestDeleteAccount(t *testing.T) {
 
 			mockStore := mockrepo.NewMockStore(ctrl)
 			tc.buildStubs(mockStore)
+			stubTokenNotRevoked(mockStore)
 
-			server := NewServer(mockStore)
+			server := newTestServer(t, mockStore)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprintf("/accounts/%d", tc.accountID)
 			request, err := http.NewRequest(http.MethodDelete, url, nil)
 			require.NoError(t, err)
 
+			tc.setupAuth(t, request, server.tokenMaker)
 			server.router.ServeHTTP(recorder, request)
 			require.Equal(t, tc.statusCode, recorder.Code)
 		})
 	}
 }
 
+func TestChangeAccountStatus(t *testing.T) {
+	user, _ := createRandomUser(t)
+	account := randomAccount(user.Username)
+
+	testCases := []struct {
+		name       string
+		action     string
+		username   string
+		role       string
+		status     string
+		buildStubs func(store *mockrepo.MockStore, changed repo.Account)
+		statusCode int
+	}{
+		{
+			name:     "Freeze",
+			action:   "freeze",
+			username: "admin",
+			role:     util.AdminRole,
+			status:   repo.AccountStatusFrozen,
+			buildStubs: func(store *mockrepo.MockStore, changed repo.Account) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Eq(repo.ChangeAccountStatusTxParams{
+						AccountID: account.ID,
+						Status:    repo.AccountStatusFrozen,
+					})).
+					Times(1).
+					Return(changed, nil)
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:     "Unfreeze",
+			action:   "unfreeze",
+			username: "admin",
+			role:     util.AdminRole,
+			status:   repo.AccountStatusActive,
+			buildStubs: func(store *mockrepo.MockStore, changed repo.Account) {
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Eq(repo.ChangeAccountStatusTxParams{
+						AccountID: account.ID,
+						Status:    repo.AccountStatusActive,
+					})).
+					Times(1).
+					Return(changed, nil)
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:     "OwnerFreeze",
+			action:   "freeze",
+			username: user.Username,
+			role:     util.DepositorRole,
+			status:   repo.AccountStatusFrozen,
+			buildStubs: func(store *mockrepo.MockStore, changed repo.Account) {
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:     "OwnerUnfreeze",
+			action:   "unfreeze",
+			username: user.Username,
+			role:     util.DepositorRole,
+			status:   repo.AccountStatusActive,
+			buildStubs: func(store *mockrepo.MockStore, changed repo.Account) {
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:     "Close",
+			action:   "close",
+			username: user.Username,
+			role:     util.DepositorRole,
+			status:   repo.AccountStatusClosed,
+			buildStubs: func(store *mockrepo.MockStore, changed repo.Account) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Eq(repo.ChangeAccountStatusTxParams{
+						AccountID: account.ID,
+						Status:    repo.AccountStatusClosed,
+					})).
+					Times(1).
+					Return(changed, nil)
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:     "CloseNotOwned",
+			action:   "close",
+			username: "someone",
+			role:     util.DepositorRole,
+			status:   repo.AccountStatusClosed,
+			buildStubs: func(store *mockrepo.MockStore, changed repo.Account) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:     "InvalidTransition",
+			action:   "unfreeze",
+			username: "admin",
+			role:     util.AdminRole,
+			status:   repo.AccountStatusActive,
+			buildStubs: func(store *mockrepo.MockStore, changed repo.Account) {
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(repo.Account{}, fmt.Errorf("%w: closed to active", repo.ErrInvalidStatusTransition))
+			},
+			statusCode: http.StatusConflict,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			changed := account
+			changed.Status = tc.status
+
+			mockStore := mockrepo.NewMockStore(ctrl)
+			tc.buildStubs(mockStore, changed)
+			stubTokenNotRevoked(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/%s", account.ID, tc.action)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.statusCode, recorder.Code)
+			if tc.statusCode == http.StatusOK {
+				requireBodyMatchAccount(t, recorder.Body, changed)
+			}
+		})
+	}
+}
+
 // utility methods:
 
 // getAccountAPI this is an example of a single test with explanation on each step. TestGetAccountAPI - this is more advance approach to unit testing
 func getAccountAPI(t *testing.T) {
 	// First we need to an account to work with
-	account := randomAccount()
+	account := randomAccount(util.RandomOwner())
 
 	// Then we need a mock database instance, we call NewMockStore method that generated by mock package
 	// This method receive gomock.Controller
@@ -534,9 +1142,10 @@ func getAccountAPI(t *testing.T) {
 		GetAccount(gomock.Any(), gomock.Eq(account.ID)).
 		Times(1).
 		Return(account, nil)
+	stubTokenNotRevoked(mockStore)
 
 	// Then we should start test server and send request
-	server := NewServer(mockStore)
+	server := newTestServer(t, mockStore)
 	// To test http server we don't have to start it
 	// We can use the feature of httptest package or record http response
 	recorder := httptest.NewRecorder()
@@ -547,6 +1156,9 @@ func getAccountAPI(t *testing.T) {
 	request, err := http.NewRequest("GET", url, nil)
 	require.NoError(t, err)
 
+	// Protected routes require a bearer token, so we sign one for the account owner
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, account.Owner, util.DepositorRole, time.Minute)
+
 	// So in this part we will serve http server - send our prepared request and write down to recorder response
 	server.router.ServeHTTP(recorder, request)
 	require.Equal(t, http.StatusOK, recorder.Code)
@@ -554,12 +1166,15 @@ func getAccountAPI(t *testing.T) {
 	requireBodyMatchAccount(t, recorder.Body, account)
 }
 
-func randomAccount() repo.Account {
+func randomAccount(owner string) repo.Account {
+	balance := util.RandomMoney()
 	return repo.Account{
-		ID:       util.RandomInt(1, 1000),
-		Owner:    util.RandomString(6),
-		Balance:  util.RandomMoney(),
-		Currency: util.RandomCurrency(),
+		ID:               util.RandomInt(1, 1000),
+		Owner:            owner,
+		Balance:          balance,
+		Currency:         util.RandomCurrency(),
+		Status:           repo.AccountStatusActive,
+		AvailableBalance: balance,
 	}
 }
 
@@ -573,22 +1188,13 @@ func requireBodyMatchAccount(t *testing.T, body *bytes.Buffer, account repo.Acco
 	require.Equal(t, account, gotAccount)
 }
 
-func requireBodyMatchAccounts(t *testing.T, body *bytes.Buffer, accounts []repo.Account) {
-	response, err := io.ReadAll(body)
-	require.NoError(t, err)
-
-	var gotAccounts []repo.Account
-	err = json.Unmarshal(response, &gotAccounts)
-	require.NoError(t, err)
-	require.Equal(t, accounts, gotAccounts)
-}
-
-func requireBodyMatchAccountUpdate(t *testing.T, body *bytes.Buffer, account repo.Account) {
+func requireBodyMatchAccounts(t *testing.T, body *bytes.Buffer, accounts []repo.Account, nextCursor string) {
 	response, err := io.ReadAll(body)
 	require.NoError(t, err)
 
-	var gotUpdatedAccount repo.Account
-	err = json.Unmarshal(response, &gotUpdatedAccount)
+	var gotPage page[repo.Account]
+	err = json.Unmarshal(response, &gotPage)
 	require.NoError(t, err)
-	require.NotEqual(t, account.Balance, gotUpdatedAccount.Balance)
+	require.Equal(t, accounts, gotPage.Items)
+	require.Equal(t, nextCursor, gotPage.NextCursor)
 }
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"net/http"
+)
+
+type balanceChangeRequest struct {
+	Amount int64 `json:"amount" binding:"required,gt=0"`
+}
+
+// createDeposit credits any account, it's an admin operation: money only enters the bank
+// once the funding outside of it was verified, a user can't credit their own account
+func (s *Server) createDeposit(ctx *gin.Context) {
+	arg, ok := bindBalanceChange(ctx)
+	if !ok {
+		return
+	}
+
+	result, err := s.store.DepositTx(ctx, arg)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// createWithdrawal takes money from an account of the authenticated user
+func (s *Server) createWithdrawal(ctx *gin.Context) {
+	arg, ok := bindBalanceChange(ctx)
+	if !ok {
+		return
+	}
+
+	if _, ok := s.ownedAccount(ctx, arg.AccountID); !ok {
+		return
+	}
+
+	result, err := s.store.WithdrawTx(ctx, arg)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// bindBalanceChange reads the account and the amount of a deposit or a withdrawal, it writes the error response itself
+func bindBalanceChange(ctx *gin.Context) (repo.BalanceChangeTxParams, bool) {
+	var uri accountURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return repo.BalanceChangeTxParams{}, false
+	}
+
+	var req balanceChangeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return repo.BalanceChangeTxParams{}, false
+	}
+
+	return repo.BalanceChangeTxParams{
+		AccountID: uri.ID,
+		Amount:    req.Amount,
+	}, true
+}
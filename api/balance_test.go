@@ -0,0 +1,275 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	mockrepo "github.com/max-rodziyevsky/go-simple-bank/internal/repo/mock"
+	"github.com/max-rodziyevsky/go-simple-bank/token"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreateDeposit(t *testing.T) {
+	user, _ := createRandomUser(t)
+	account := randomAccount(user.Username)
+	amount := util.RandomMoney()
+
+	result := randomBalanceChange(account, amount, repo.EntryKindDeposit)
+
+	testCases := []struct {
+		name          string
+		accountID     int64
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(mockStore *mockrepo.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			accountID: account.ID,
+			body:      gin.H{"amount": amount},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "admin", util.AdminRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				arg := repo.BalanceChangeTxParams{
+					AccountID: account.ID,
+					Amount:    amount,
+				}
+
+				mockStore.EXPECT().DepositTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchBalanceChange(t, recorder.Body, result)
+			},
+		},
+		{
+			name:      "Owner",
+			accountID: account.ID,
+			body:      gin.H{"amount": amount},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			accountID: account.ID,
+			body:      gin.H{"amount": amount},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "AccountNotFound",
+			accountID: account.ID,
+			body:      gin.H{"amount": amount},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "admin", util.AdminRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(1).Return(repo.BalanceChangeTxResult{}, repo.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidAmount",
+			accountID: account.ID,
+			body:      gin.H{"amount": -amount},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "admin", util.AdminRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidAccountID",
+			accountID: 0,
+			body:      gin.H{"amount": amount},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "admin", util.AdminRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InternalServerError",
+			accountID: account.ID,
+			body:      gin.H{"amount": amount},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "admin", util.AdminRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(1).Return(repo.BalanceChangeTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mockrepo.NewMockStore(ctrl)
+			tc.buildStubs(mockStore)
+			stubTokenNotRevoked(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d/deposits", tc.accountID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestCreateWithdrawal(t *testing.T) {
+	user, _ := createRandomUser(t)
+	account := randomAccount(user.Username)
+	amount := util.RandomInt(1, account.Balance)
+
+	result := randomBalanceChange(account, -amount, repo.EntryKindWithdrawal)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(mockStore *mockrepo.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				arg := repo.BalanceChangeTxParams{
+					AccountID: account.ID,
+					Amount:    amount,
+				}
+
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				mockStore.EXPECT().WithdrawTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchBalanceChange(t, recorder.Body, result)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				mockStore.EXPECT().WithdrawTx(gomock.Any(), gomock.Any()).Times(1).Return(repo.BalanceChangeTxResult{}, repo.ErrInsufficientFunds)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "LimitExceeded",
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				err := &repo.LimitExceededError{Limit: repo.LimitAccountDaily, Max: amount, Used: 1}
+
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				mockStore.EXPECT().WithdrawTx(gomock.Any(), gomock.Any()).Times(1).Return(repo.BalanceChangeTxResult{}, err)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeLimitExceeded)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mockrepo.NewMockStore(ctrl)
+			tc.buildStubs(mockStore)
+			stubTokenNotRevoked(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"amount": amount})
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d/withdrawals", account.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func randomBalanceChange(account repo.Account, amount int64, kind string) repo.BalanceChangeTxResult {
+	result := repo.BalanceChangeTxResult{
+		Account: account,
+		Entry: repo.Entry{
+			ID:        util.RandomInt(1, 1000),
+			AccountID: account.ID,
+			Amount:    amount,
+			Kind:      kind,
+			Metadata:  json.RawMessage(`{}`),
+		},
+	}
+	result.Account.Balance += amount
+
+	return result
+}
+
+func requireBodyMatchBalanceChange(t *testing.T, body *bytes.Buffer, result repo.BalanceChangeTxResult) {
+	response, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var got repo.BalanceChangeTxResult
+	err = json.Unmarshal(response, &got)
+	require.NoError(t, err)
+	require.Equal(t, result, got)
+}
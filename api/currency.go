@@ -0,0 +1,11 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// listCurrencies returns currencies which can be used for new accounts and transfers
+func (s *Server) listCurrencies(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, s.currencies.Enabled())
+}
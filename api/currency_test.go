@@ -0,0 +1,37 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	mockrepo "github.com/max-rodziyevsky/go-simple-bank/internal/repo/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListCurrencies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// currencies are served from the registry, so the store isn't called
+	mockStore := mockrepo.NewMockStore(ctrl)
+	mockStore.EXPECT().ListCurrencies(gomock.Any()).Times(0)
+
+	server := newTestServer(t, mockStore)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/currencies", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var currencies []repo.Currency
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &currencies))
+	require.Equal(t, server.currencies.Enabled(), currencies)
+	for _, currency := range currencies {
+		require.NotEqual(t, disabledCurrency, currency.Code)
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"github.com/max-rodziyevsky/go-simple-bank/token"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"net/http"
+	"time"
+)
+
+type listAccountEntriesRequest struct {
+	From     time.Time `form:"from"`
+	To       time.Time `form:"to"`
+	Cursor   string    `form:"cursor"`
+	PageSize int32     `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// listAccountEntries returns ledger lines of the account in chronological order.
+// Every line carries the balance reached by all entries up to and including it.
+// Admins may list the entries of any account, so support staff can look into customer accounts.
+func (s *Server) listAccountEntries(ctx *gin.Context) {
+	var uri accountURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	var req listAccountEntriesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	to, err := periodEnd(req.From, req.To)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	cursor, err := decodeCursor(req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Role != util.AdminRole {
+		if _, ok := s.ownedAccount(ctx, uri.ID); !ok {
+			return
+		}
+	}
+
+	pageSize := pageSizeOrDefault(req.PageSize)
+	entries, err := s.store.ListEntriesByAccountID(ctx, repo.ListEntriesByAccountIDParams{
+		AccountID:      uri.ID,
+		FromTime:       req.From,
+		AfterCreatedAt: cursor.CreatedAt,
+		AfterID:        cursor.ID,
+		ToTime:         to,
+		PageSize:       pageSize + 1,
+	})
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newPage(entries, pageSize, func(entry repo.ListEntriesByAccountIDRow) pageCursor {
+		return pageCursor{CreatedAt: entry.CreatedAt, ID: entry.ID}
+	}))
+}
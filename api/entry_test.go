@@ -0,0 +1,219 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	mockrepo "github.com/max-rodziyevsky/go-simple-bank/internal/repo/mock"
+	"github.com/max-rodziyevsky/go-simple-bank/token"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestListAccountEntries(t *testing.T) {
+	user, _ := createRandomUser(t)
+	otherUser, _ := createRandomUser(t)
+	account := randomAccount(user.Username)
+
+	n := 3
+	entries := make([]repo.ListEntriesByAccountIDRow, n)
+	var balance int64
+	for i := range entries {
+		amount := util.RandomInt(-1000, 1000)
+		balance += amount
+		entries[i] = repo.ListEntriesByAccountIDRow{
+			ID:             int64(i + 1),
+			AccountID:      account.ID,
+			Amount:         amount,
+			Kind:           repo.EntryKindTransfer,
+			Description:    util.RandomString(12),
+			Metadata:       json.RawMessage(`{"order":"42"}`),
+			CreatedAt:      time.Now().UTC().Truncate(time.Second),
+			RunningBalance: balance,
+		}
+	}
+
+	cursor := pageCursor{CreatedAt: entries[n-2].CreatedAt, ID: entries[n-2].ID}
+	from := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(mockStore *mockrepo.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: url.Values{},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				arg := repo.ListEntriesByAccountIDParams{
+					AccountID: account.ID,
+					ToTime:    maxTime,
+					PageSize:  defaultPageSize + 1,
+				}
+
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				mockStore.EXPECT().ListEntriesByAccountID(gomock.Any(), gomock.Eq(arg)).Times(1).Return(entries, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got page[repo.ListEntriesByAccountIDRow]
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, entries, got.Items)
+				require.Empty(t, got.NextCursor)
+			},
+		},
+		{
+			name: "NextPage",
+			query: url.Values{
+				"from":      {from.Format(time.RFC3339)},
+				"cursor":    {cursor.encode()},
+				"page_size": {fmt.Sprint(n - 1)},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				arg := repo.ListEntriesByAccountIDParams{
+					AccountID:      account.ID,
+					FromTime:       from,
+					AfterCreatedAt: cursor.CreatedAt,
+					AfterID:        cursor.ID,
+					ToTime:         maxTime,
+					PageSize:       int32(n),
+				}
+
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				mockStore.EXPECT().ListEntriesByAccountID(gomock.Any(), gomock.Eq(arg)).Times(1).Return(entries, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got page[repo.ListEntriesByAccountIDRow]
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, entries[:n-1], got.Items)
+				require.Equal(t, cursor.encode(), got.NextCursor)
+			},
+		},
+		{
+			name:  "NotOwner",
+			query: url.Values{},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, otherUser.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				mockStore.EXPECT().ListEntriesByAccountID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "Admin",
+			query: url.Values{},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "admin", util.AdminRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				mockStore.EXPECT().ListEntriesByAccountID(gomock.Any(), gomock.Any()).Times(1).Return(entries, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got page[repo.ListEntriesByAccountIDRow]
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, entries, got.Items)
+			},
+		},
+		{
+			name:  "NoAuthorization",
+			query: url.Values{},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().ListEntriesByAccountID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidPeriod",
+			query: url.Values{"from": {from.Format(time.RFC3339)}, "to": {from.Format(time.RFC3339)}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().ListEntriesByAccountID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidCursor",
+			query: url.Values{"cursor": {"%%%"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().ListEntriesByAccountID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: url.Values{},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				mockStore.EXPECT().ListEntriesByAccountID(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mockrepo.NewMockStore(ctrl)
+			tc.buildStubs(mockStore)
+			stubTokenNotRevoked(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/entries?%s", account.ID, tc.query.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
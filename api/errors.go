@@ -0,0 +1,78 @@
+package api
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"net/http"
+)
+
+// Error codes are a part of the API contract, clients should rely on them rather than on messages
+const (
+	codeInvalidRequest       = "invalid_request"
+	codeUnauthorized         = "unauthorized"
+	codeForbidden            = "forbidden"
+	codeNotFound             = "not_found"
+	codeAlreadyExists        = "already_exists"
+	codeInvalidReference     = "invalid_reference"
+	codeInsufficientFunds    = "insufficient_funds"
+	codeCurrencyMismatch     = "currency_mismatch"
+	codeIdempotencyKeyReused = "idempotency_key_reused"
+	codeRateNotFound         = "rate_not_found"
+	codeAmountTooSmall       = "amount_too_small"
+	codeAccountNotActive     = "account_not_active"
+	codeInvalidTransition    = "invalid_status_transition"
+	codeBalanceNotZero       = "balance_not_zero"
+	codeReversalExceeds      = "reversal_exceeds_transfer"
+	codeInvalidReversal      = "invalid_reversal"
+	codeLimitExceeded        = "transfer_limit_exceeded"
+	codeTransferStatus       = "invalid_transfer_status"
+	codeInternal             = "internal_error"
+)
+
+var errInternal = errors.New("internal server error")
+
+// storeErrors maps domain errors of the store to the response status and code
+var storeErrors = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{repo.ErrRecordNotFound, http.StatusNotFound, codeNotFound},
+	{repo.ErrUniqueViolation, http.StatusForbidden, codeAlreadyExists},
+	{repo.ErrForeignKeyViolation, http.StatusForbidden, codeInvalidReference},
+	{repo.ErrInsufficientFunds, http.StatusUnprocessableEntity, codeInsufficientFunds},
+	{repo.ErrCurrencyMismatch, http.StatusBadRequest, codeCurrencyMismatch},
+	{repo.ErrRateNotFound, http.StatusUnprocessableEntity, codeRateNotFound},
+	{repo.ErrAmountTooSmall, http.StatusUnprocessableEntity, codeAmountTooSmall},
+	{repo.ErrAccountNotActive, http.StatusUnprocessableEntity, codeAccountNotActive},
+	{repo.ErrInvalidStatusTransition, http.StatusConflict, codeInvalidTransition},
+	{repo.ErrBalanceNotZero, http.StatusConflict, codeBalanceNotZero},
+	{repo.ErrReversalExceedsTransfer, http.StatusUnprocessableEntity, codeReversalExceeds},
+	{repo.ErrReversalOfReversal, http.StatusUnprocessableEntity, codeInvalidReversal},
+	{repo.ErrLimitExceeded, http.StatusUnprocessableEntity, codeLimitExceeded},
+	{repo.ErrInvalidTransferStatus, http.StatusConflict, codeTransferStatus},
+	// the key is only reported when its stored response is gone, e.g. swept right after the conflict
+	{repo.ErrIdempotencyKeyExists, http.StatusConflict, codeIdempotencyKeyReused},
+}
+
+// handleError writes the error response for an error returned by the store or any other dependency.
+// Unknown errors are hidden behind a generic message and attached to the context to get logged.
+func handleError(ctx *gin.Context, err error) {
+	for _, e := range storeErrors {
+		if errors.Is(err, e.err) {
+			ctx.JSON(e.status, errorResponse(e.code, err))
+			return
+		}
+	}
+
+	_ = ctx.Error(err)
+	ctx.JSON(http.StatusInternalServerError, errorResponse(codeInternal, errInternal))
+}
+
+func errorResponse(code string, err error) gin.H {
+	return gin.H{
+		"code":  code,
+		"error": err.Error(),
+	}
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleError(t *testing.T) {
+	testCases := []struct {
+		name    string
+		err     error
+		status  int
+		code    string
+		message string
+	}{
+		{
+			name:   "NotFound",
+			err:    repo.ErrRecordNotFound,
+			status: http.StatusNotFound,
+			code:   codeNotFound,
+		},
+		{
+			name:   "UniqueViolation",
+			err:    repo.ErrUniqueViolation,
+			status: http.StatusForbidden,
+			code:   codeAlreadyExists,
+		},
+		{
+			name:   "ForeignKeyViolation",
+			err:    repo.ErrForeignKeyViolation,
+			status: http.StatusForbidden,
+			code:   codeInvalidReference,
+		},
+		{
+			name:   "InsufficientFunds",
+			err:    repo.ErrInsufficientFunds,
+			status: http.StatusUnprocessableEntity,
+			code:   codeInsufficientFunds,
+		},
+		{
+			name:    "WrappedCurrencyMismatch",
+			err:     fmt.Errorf("%w: USD vs EUR", repo.ErrCurrencyMismatch),
+			status:  http.StatusBadRequest,
+			code:    codeCurrencyMismatch,
+			message: "currency mismatch: USD vs EUR",
+		},
+		{
+			name:    "WrappedAccountNotActive",
+			err:     fmt.Errorf("%w: account 1 is frozen", repo.ErrAccountNotActive),
+			status:  http.StatusUnprocessableEntity,
+			code:    codeAccountNotActive,
+			message: "account is not active: account 1 is frozen",
+		},
+		{
+			name:    "LimitExceeded",
+			err:     &repo.LimitExceededError{Limit: repo.LimitAccountDaily, Max: 1000, Used: 900},
+			status:  http.StatusUnprocessableEntity,
+			code:    codeLimitExceeded,
+			message: "transfer limit exceeded: account_daily_limit is 1000, 900 already used",
+		},
+		{
+			name:    "InvalidTransferStatus",
+			err:     fmt.Errorf("%w: transfer 7 is voided", repo.ErrInvalidTransferStatus),
+			status:  http.StatusConflict,
+			code:    codeTransferStatus,
+			message: "invalid transfer status: transfer 7 is voided",
+		},
+		{
+			name:   "IdempotencyKeyExists",
+			err:    repo.ErrIdempotencyKeyExists,
+			status: http.StatusConflict,
+			code:   codeIdempotencyKeyReused,
+		},
+		{
+			name:    "Unknown",
+			err:     sql.ErrConnDone,
+			status:  http.StatusInternalServerError,
+			code:    codeInternal,
+			message: errInternal.Error(),
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+
+			handleError(ctx, tc.err)
+			require.Equal(t, tc.status, recorder.Code)
+
+			var body map[string]string
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+			require.Equal(t, tc.code, body["code"])
+			if len(tc.message) > 0 {
+				require.Equal(t, tc.message, body["error"])
+			}
+		})
+	}
+}
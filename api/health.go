@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"net/http"
+	"time"
+)
+
+const (
+	statusOK          = "ok"
+	statusUnavailable = "unavailable"
+)
+
+// readinessTimeout bounds every dependency check, so a hanging database fails the probe instead of blocking it
+const readinessTimeout = 2 * time.Second
+
+var (
+	errDependencyUnavailable = errors.New("dependency is unavailable")
+	errSchemaBehind          = errors.New("database schema is behind the code")
+	errDirtyMigration        = errors.New("last migration is dirty")
+)
+
+// reportedCheckErrors are shown as they are. The probe is unauthenticated, so any other error
+// is replaced by errDependencyUnavailable, the raw errors are attached to the context to get logged.
+var reportedCheckErrors = []error{errSchemaBehind, errDirtyMigration}
+
+type healthResponse struct {
+	Status string `json:"status"`
+	// Checks has the status of every dependency, it's only filled by the readiness probe
+	Checks map[string]dependencyCheck `json:"checks,omitempty"`
+}
+
+type dependencyCheck struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// healthz tells the process is up, it doesn't check any dependency
+func (s *Server) healthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, healthResponse{Status: statusOK})
+}
+
+// readyz tells whether the server can handle requests: the database is reachable
+// and its schema is migrated to the version the code relies on
+func (s *Server) readyz(ctx *gin.Context) {
+	response := healthResponse{
+		Status: statusOK,
+		Checks: map[string]dependencyCheck{
+			"database":   checkDependency(ctx, s.store.Ping),
+			"migrations": checkDependency(ctx, s.checkMigrations),
+		},
+	}
+
+	status := http.StatusOK
+	for _, check := range response.Checks {
+		if check.Status != statusOK {
+			response.Status = statusUnavailable
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	ctx.JSON(status, response)
+}
+
+// checkMigrations fails when the schema is older than the code or a migration failed halfway.
+// A newer schema is fine: migrations are applied before the code which relies on them is rolled out.
+func (s *Server) checkMigrations(ctx context.Context) error {
+	migration, err := s.store.GetMigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	if migration.Dirty {
+		return errDirtyMigration
+	}
+	if migration.Version < repo.SchemaVersion {
+		return errSchemaBehind
+	}
+	return nil
+}
+
+func checkDependency(ctx *gin.Context, check func(ctx context.Context) error) dependencyCheck {
+	checkCtx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(checkCtx)
+	result := dependencyCheck{
+		Status:    statusOK,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = statusUnavailable
+		result.Error = errDependencyUnavailable.Error()
+		for _, reported := range reportedCheckErrors {
+			if errors.Is(err, reported) {
+				result.Error = err.Error()
+			}
+		}
+		_ = ctx.Error(err)
+	}
+
+	return result
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	mockrepo "github.com/max-rodziyevsky/go-simple-bank/internal/repo/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthz(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mockrepo.NewMockStore(ctrl)
+	mockStore.EXPECT().Ping(gomock.Any()).Times(0)
+
+	server := newTestServer(t, mockStore)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/healthz", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var got healthResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	require.Equal(t, healthResponse{Status: statusOK}, got)
+}
+
+func TestReadyz(t *testing.T) {
+	testCases := []struct {
+		name          string
+		buildStubs    func(mockStore *mockrepo.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				mockStore.EXPECT().
+					GetMigrationStatus(gomock.Any()).
+					Times(1).
+					Return(repo.MigrationStatus{Version: repo.SchemaVersion}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				got := requireBodyMatchHealth(t, recorder, statusOK)
+				require.Equal(t, statusOK, got.Checks["database"].Status)
+				require.Equal(t, statusOK, got.Checks["migrations"].Status)
+				require.Empty(t, got.Checks["database"].Error)
+			},
+		},
+		{
+			name: "DatabaseDown",
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().Ping(gomock.Any()).Times(1).Return(sql.ErrConnDone)
+				mockStore.EXPECT().GetMigrationStatus(gomock.Any()).Times(1).Return(repo.MigrationStatus{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+				got := requireBodyMatchHealth(t, recorder, statusUnavailable)
+				require.Equal(t, statusUnavailable, got.Checks["database"].Status)
+				require.Equal(t, errDependencyUnavailable.Error(), got.Checks["database"].Error)
+				require.Equal(t, errDependencyUnavailable.Error(), got.Checks["migrations"].Error)
+				require.NotContains(t, recorder.Body.String(), sql.ErrConnDone.Error())
+			},
+		},
+		{
+			name: "SchemaBehind",
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				mockStore.EXPECT().
+					GetMigrationStatus(gomock.Any()).
+					Times(1).
+					Return(repo.MigrationStatus{Version: repo.SchemaVersion - 1}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+				got := requireBodyMatchHealth(t, recorder, statusUnavailable)
+				require.Equal(t, statusOK, got.Checks["database"].Status)
+				require.Equal(t, statusUnavailable, got.Checks["migrations"].Status)
+				require.Equal(t, errSchemaBehind.Error(), got.Checks["migrations"].Error)
+			},
+		},
+		{
+			name: "SchemaAhead",
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				mockStore.EXPECT().
+					GetMigrationStatus(gomock.Any()).
+					Times(1).
+					Return(repo.MigrationStatus{Version: repo.SchemaVersion + 1}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				got := requireBodyMatchHealth(t, recorder, statusOK)
+				require.Equal(t, statusOK, got.Checks["migrations"].Status)
+			},
+		},
+		{
+			name: "DirtyMigration",
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				mockStore.EXPECT().
+					GetMigrationStatus(gomock.Any()).
+					Times(1).
+					Return(repo.MigrationStatus{Version: repo.SchemaVersion, Dirty: true}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+				got := requireBodyMatchHealth(t, recorder, statusUnavailable)
+				require.Equal(t, errDirtyMigration.Error(), got.Checks["migrations"].Error)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mockrepo.NewMockStore(ctrl)
+			tc.buildStubs(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/readyz", nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireBodyMatchHealth(t *testing.T, recorder *httptest.ResponseRecorder, status string) healthResponse {
+	var got healthResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	require.Equal(t, status, got.Status)
+	require.Len(t, got.Checks, 2)
+
+	return got
+}
@@ -1,11 +1,55 @@
 package api
 
 import (
+	"context"
 	"github.com/gin-gonic/gin"
+	"github.com/max-rodziyevsky/go-simple-bank/configs"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/currency"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"github.com/max-rodziyevsky/go-simple-bank/token"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"github.com/stretchr/testify/require"
 	"os"
 	"testing"
+	"time"
 )
 
+func newTestServer(t *testing.T, store repo.Store) *Server {
+	config := configs.Config{
+		TokenType:            token.PasetoType,
+		TokenSymmetricKey:    util.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+		ReadTimeout:          time.Second,
+		WriteTimeout:         time.Second,
+		IdleTimeout:          time.Minute,
+		MaxHeaderBytes:       1 << 16,
+	}
+
+	currencies, err := currency.NewRegistry(context.Background(), testCurrencies{}, time.Hour)
+	require.NoError(t, err)
+
+	server, err := NewServer(config, store, currencies)
+	require.NoError(t, err)
+
+	return server
+}
+
+// disabledCurrency is known to test servers, but can't be used
+const disabledCurrency = "GBP"
+
+// testCurrencies is the currency source of test servers
+type testCurrencies struct{}
+
+func (testCurrencies) ListCurrencies(_ context.Context) ([]repo.Currency, error) {
+	return []repo.Currency{
+		{Code: util.EUR, Exponent: 2, Enabled: true},
+		{Code: disabledCurrency, Exponent: 2, Enabled: false},
+		{Code: util.UAH, Exponent: 2, Enabled: true},
+		{Code: util.USD, Exponent: 2, Enabled: true},
+	}, nil
+}
+
 func TestMain(m *testing.M) {
 	gin.SetMode(gin.TestMode)
 	os.Exit(m.Run())
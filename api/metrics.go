@@ -0,0 +1,60 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// unmatchedRoute labels requests which match no route, so random paths don't create new series
+const unmatchedRoute = "unmatched"
+
+// metricsReadHeaderTimeout keeps a slow client from holding a connection of the metrics server
+const metricsReadHeaderTimeout = 10 * time.Second
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by route and response status.",
+	}, []string{"method", "route", "status"})
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of HTTP requests by route and response status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// metricsMiddleware counts requests and observes their duration. Requests are labeled
+// with the route pattern, like /accounts/:id, rather than the path, which has ids in it.
+func metricsMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(ctx.Writer.Status())
+
+		httpRequests.WithLabelValues(ctx.Request.Method, route, status).Inc()
+		httpRequestDuration.WithLabelValues(ctx.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// NewMetricsServer serves the metrics on their own address. They tell transfer volumes and error rates,
+// so they are kept off the public API port and the address should only be reachable by the scraper.
+func NewMetricsServer(address string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	return &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: metricsReadHeaderTimeout,
+	}
+}
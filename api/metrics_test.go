@@ -0,0 +1,48 @@
+package api
+
+import (
+	"github.com/golang/mock/gomock"
+	mockrepo "github.com/max-rodziyevsky/go-simple-bank/internal/repo/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockrepo.NewMockStore(ctrl))
+
+	for _, path := range []string{"/accounts/42", "/no/such/route"} {
+		request, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		server.router.ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	// the public API doesn't serve the metrics
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	NewMetricsServer("").Handler.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Header().Get("Content-Type"), "text/plain; version=0.0.4")
+
+	// requests are labeled with the route pattern, not the path
+	body := recorder.Body.String()
+	require.Contains(t, body, `http_requests_total{method="GET",route="/accounts/:id",status="401"}`)
+	require.Contains(t, body, `http_requests_total{method="GET",route="unmatched",status="404"}`)
+	require.Contains(t, body, `http_request_duration_seconds_bucket{method="GET",route="/accounts/:id",status="401",le="+Inf"}`)
+	require.NotContains(t, body, "/accounts/42")
+
+	// the default registry exposes the metrics of the Go runtime too
+	require.Contains(t, body, "# TYPE go_goroutines gauge")
+	// and the store registers its metrics with it, a counter without labels is exposed before it's increased
+	require.Contains(t, body, "# TYPE bank_db_deadlock_retries_total counter")
+}
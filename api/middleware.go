@@ -0,0 +1,99 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"github.com/max-rodziyevsky/go-simple-bank/token"
+	"net/http"
+	"strings"
+)
+
+const (
+	authorizationHeaderKey  = "authorization"
+	authorizationTypeBearer = "bearer"
+	authorizationPayloadKey = "authorization_payload"
+)
+
+var (
+	errRevokedToken   = errors.New("token has been revoked")
+	errBlockedSession = errors.New("session has been blocked")
+)
+
+// authMiddleware verifies the bearer token of the request and stores its payload in the context
+func authMiddleware(tokenMaker token.Maker, store repo.Store) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
+		if len(authorizationHeader) == 0 {
+			err := errors.New("authorization header is not provided")
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(codeUnauthorized, err))
+			return
+		}
+
+		// header must look like: Bearer <token>
+		fields := strings.Fields(authorizationHeader)
+		if len(fields) != 2 {
+			err := errors.New("invalid authorization header format")
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(codeUnauthorized, err))
+			return
+		}
+
+		authorizationType := strings.ToLower(fields[0])
+		if authorizationType != authorizationTypeBearer {
+			err := fmt.Errorf("unsupported authorization type %s", authorizationType)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(codeUnauthorized, err))
+			return
+		}
+
+		payload, err := tokenMaker.VerifyToken(fields[1], token.AccessToken)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(codeUnauthorized, err))
+			return
+		}
+
+		// a token can be revoked on logout before it expires
+		revoked, err := store.IsTokenRevoked(ctx, payload.ID)
+		if err != nil {
+			handleError(ctx, err)
+			ctx.Abort()
+			return
+		}
+		if revoked {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(codeUnauthorized, errRevokedToken))
+			return
+		}
+
+		// blocking a session on logout or by an admin rejects every access token issued for it
+		blocked, err := store.IsSessionBlocked(ctx, payload.SessionID)
+		if err != nil {
+			handleError(ctx, err)
+			ctx.Abort()
+			return
+		}
+		if blocked {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(codeUnauthorized, errBlockedSession))
+			return
+		}
+
+		ctx.Set(authorizationPayloadKey, payload)
+		ctx.Next()
+	}
+}
+
+// roleMiddleware lets the request through only if the authenticated user has one of the given roles.
+// It must be used after authMiddleware.
+func roleMiddleware(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		for _, role := range roles {
+			if payload.Role == role {
+				ctx.Next()
+				return
+			}
+		}
+
+		err := fmt.Errorf("role %s is not allowed to access this resource", payload.Role)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(codeForbidden, err))
+	}
+}
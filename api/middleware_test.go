@@ -0,0 +1,270 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	mockrepo "github.com/max-rodziyevsky/go-simple-bank/internal/repo/mock"
+	"github.com/max-rodziyevsky/go-simple-bank/token"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func addAuthorization(
+	t *testing.T,
+	request *http.Request,
+	tokenMaker token.Maker,
+	authorizationType string,
+	username string,
+	role string,
+	duration time.Duration,
+) {
+	accessToken, payload, err := tokenMaker.CreateToken(username, role, token.AccessToken, uuid.New(), duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	authorizationHeader := fmt.Sprintf("%s %s", authorizationType, accessToken)
+	request.Header.Set(authorizationHeaderKey, authorizationHeader)
+}
+
+// stubTokenNotRevoked lets every token pass the revocation checks, so handler tests only describe handler calls
+func stubTokenNotRevoked(mockStore *mockrepo.MockStore) {
+	mockStore.EXPECT().
+		IsTokenRevoked(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(false, nil)
+	mockStore.EXPECT().
+		IsSessionBlocked(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(false, nil)
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	username := util.RandomOwner()
+	sessionID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(mockStore *mockrepo.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+				mockStore.EXPECT().IsSessionBlocked(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "UnsupportedAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, "unsupported", username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidAuthorizationFormat",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, "", username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredToken",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, username, util.DepositorRole, -time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "RefreshToken",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				refreshToken, _, err := tokenMaker.CreateToken(username, util.DepositorRole, token.RefreshToken, uuid.Nil, time.Minute)
+				require.NoError(t, err)
+				request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, refreshToken))
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "RevokedToken",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(1).Return(true, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "RevocationCheckError",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(1).Return(false, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "BlockedSession",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				accessToken, payload, err := tokenMaker.CreateToken(username, util.DepositorRole, token.AccessToken, sessionID, time.Minute)
+				require.NoError(t, err)
+				require.Equal(t, sessionID, payload.SessionID)
+				request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+				mockStore.EXPECT().IsSessionBlocked(gomock.Any(), gomock.Eq(sessionID)).Times(1).Return(true, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "SessionCheckError",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+				mockStore.EXPECT().IsSessionBlocked(gomock.Any(), gomock.Any()).Times(1).Return(false, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mockrepo.NewMockStore(ctrl)
+			tc.buildStubs(mockStore)
+
+			server := newTestServer(t, mockStore)
+
+			authPath := "/auth"
+			server.router.GET(
+				authPath,
+				authMiddleware(server.tokenMaker, server.store),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestRoleMiddleware(t *testing.T) {
+	username := util.RandomOwner()
+
+	testCases := []struct {
+		name       string
+		role       string
+		statusCode int
+	}{
+		{
+			name:       "Allowed",
+			role:       util.AdminRole,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Forbidden",
+			role:       util.DepositorRole,
+			statusCode: http.StatusForbidden,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mockrepo.NewMockStore(ctrl)
+			stubTokenNotRevoked(mockStore)
+
+			server := newTestServer(t, mockStore)
+
+			rolePath := "/role"
+			server.router.GET(
+				rolePath,
+				authMiddleware(server.tokenMaker, server.store),
+				roleMiddleware(util.AdminRole),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, rolePath, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.statusCode, recorder.Code)
+		})
+	}
+}
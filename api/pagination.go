@@ -0,0 +1,88 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+var (
+	errInvalidCursor = errors.New("invalid cursor")
+	errInvalidPeriod = errors.New("to must be after from")
+	// maxTime is used when the period has no end
+	maxTime = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+)
+
+// pageCursor points at the last item of a page, the next page starts right after it.
+// Clients get it as an opaque string and must not build it themselves.
+type pageCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        int64     `json:"id"`
+}
+
+func (c pageCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor returns the zero cursor for an empty string, which points before the first item
+func decodeCursor(cursor string) (pageCursor, error) {
+	var c pageCursor
+	if len(cursor) == 0 {
+		return c, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return c, errInvalidCursor
+	}
+	if err = json.Unmarshal(data, &c); err != nil {
+		return c, errInvalidCursor
+	}
+
+	return c, nil
+}
+
+// page is the envelope of paginated lists, next_cursor is omitted on the last page
+type page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// newPage expects one item more than the page size to be fetched, it tells whether there is a next page
+func newPage[T any](items []T, pageSize int32, cursorOf func(T) pageCursor) page[T] {
+	if len(items) <= int(pageSize) {
+		return page[T]{Items: items}
+	}
+
+	items = items[:pageSize]
+	return page[T]{
+		Items:      items,
+		NextCursor: cursorOf(items[len(items)-1]).encode(),
+	}
+}
+
+// pageSizeOrDefault applies the default page size when the client hasn't asked for one
+func pageSizeOrDefault(pageSize int32) int32 {
+	if pageSize == 0 {
+		return defaultPageSize
+	}
+	return pageSize
+}
+
+// periodEnd returns the end of the [from, to) period of a list filter, a zero end means there is no end
+func periodEnd(from, to time.Time) (time.Time, error) {
+	if to.IsZero() {
+		to = maxTime
+	}
+	if !to.After(from) {
+		return to, errInvalidPeriod
+	}
+	return to, nil
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/max-rodziyevsky/go-simple-bank/token"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"net/http"
+)
+
+type pendingTransferURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// captureTransfer moves the money held by an authorized transfer
+func (s *Server) captureTransfer(ctx *gin.Context) {
+	var uri pendingTransferURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	if !s.canSettleTransfer(ctx, uri.ID) {
+		return
+	}
+
+	result, err := s.store.CaptureTransferTx(ctx, uri.ID)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// voidTransfer releases the money held by an authorized transfer back to the sender
+func (s *Server) voidTransfer(ctx *gin.Context) {
+	var uri pendingTransferURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	if !s.canSettleTransfer(ctx, uri.ID) {
+		return
+	}
+
+	result, err := s.store.VoidTransferTx(ctx, uri.ID)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// canSettleTransfer reports whether the authenticated user may capture or void the transfer.
+// The payee decides, like a merchant does with a card authorization, so only the owner
+// of the destination account or an admin may settle it. It writes the error response itself.
+func (s *Server) canSettleTransfer(ctx *gin.Context, id int64) bool {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Role == util.AdminRole {
+		return true
+	}
+
+	transfer, err := s.store.GetTransfer(ctx, id)
+	if err != nil {
+		handleError(ctx, err)
+		return false
+	}
+
+	_, ok := s.ownedAccount(ctx, transfer.ToAccountID)
+	return ok
+}
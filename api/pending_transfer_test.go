@@ -0,0 +1,275 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	mockrepo "github.com/max-rodziyevsky/go-simple-bank/internal/repo/mock"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCaptureTransfer(t *testing.T) {
+	sender, _ := createRandomUser(t)
+	receiver, _ := createRandomUser(t)
+	fromAccount := randomAccount(sender.Username)
+	toAccount := randomAccount(receiver.Username)
+
+	pending := randomPendingTransfer(fromAccount.ID, toAccount.ID)
+	posted := pending
+	posted.Status = repo.TransferStatusPosted
+	result := repo.TransferTxResult{
+		Transfer: posted,
+		FromEntry: repo.Entry{
+			AccountID:  fromAccount.ID,
+			Amount:     -posted.Amount,
+			TransferID: &posted.ID,
+			Kind:       repo.EntryKindTransfer,
+			Metadata:   posted.Metadata,
+		},
+		ToEntry: repo.Entry{
+			AccountID:  toAccount.ID,
+			Amount:     posted.ToAmount,
+			TransferID: &posted.ID,
+			Kind:       repo.EntryKindTransfer,
+			Metadata:   posted.Metadata,
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		transferID    int64
+		username      string
+		role          string
+		buildStubs    func(mockStore *mockrepo.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "CapturedByReceiver",
+			transferID: pending.ID,
+			username:   receiver.Username,
+			role:       util.DepositorRole,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				mockStore.EXPECT().CaptureTransferTx(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				data, err := io.ReadAll(recorder.Body)
+				require.NoError(t, err)
+
+				var got repo.TransferTxResult
+				require.NoError(t, json.Unmarshal(data, &got))
+				require.Equal(t, result, got)
+			},
+		},
+		{
+			name:       "Admin",
+			transferID: pending.ID,
+			username:   "admin",
+			role:       util.AdminRole,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetTransfer(gomock.Any(), gomock.Any()).Times(0)
+				mockStore.EXPECT().CaptureTransferTx(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:       "Sender",
+			transferID: pending.ID,
+			username:   sender.Username,
+			role:       util.DepositorRole,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				mockStore.EXPECT().CaptureTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:       "NotPending",
+			transferID: pending.ID,
+			username:   "admin",
+			role:       util.AdminRole,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().
+					CaptureTransferTx(gomock.Any(), gomock.Eq(pending.ID)).
+					Times(1).
+					Return(repo.TransferTxResult{}, fmt.Errorf("%w: transfer %d is voided", repo.ErrInvalidTransferStatus, pending.ID))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:       "TransferNotFound",
+			transferID: pending.ID,
+			username:   receiver.Username,
+			role:       util.DepositorRole,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(repo.Transfer{}, repo.ErrRecordNotFound)
+				mockStore.EXPECT().CaptureTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:       "InvalidID",
+			transferID: 0,
+			username:   "admin",
+			role:       util.AdminRole,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().CaptureTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mockrepo.NewMockStore(ctrl)
+			tc.buildStubs(mockStore)
+			stubTokenNotRevoked(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfers/%d/capture", tc.transferID)
+			request, err := http.NewRequest(http.MethodPost, url, http.NoBody)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestVoidTransfer(t *testing.T) {
+	sender, _ := createRandomUser(t)
+	receiver, _ := createRandomUser(t)
+	fromAccount := randomAccount(sender.Username)
+	toAccount := randomAccount(receiver.Username)
+
+	pending := randomPendingTransfer(fromAccount.ID, toAccount.ID)
+	voided := pending
+	voided.Status = repo.TransferStatusVoided
+	result := repo.PendingTransferTxResult{
+		Transfer:    voided,
+		FromAccount: fromAccount,
+		ToAccount:   toAccount,
+	}
+
+	testCases := []struct {
+		name          string
+		transferID    int64
+		username      string
+		role          string
+		buildStubs    func(mockStore *mockrepo.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "VoidedByReceiver",
+			transferID: pending.ID,
+			username:   receiver.Username,
+			role:       util.DepositorRole,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				mockStore.EXPECT().VoidTransferTx(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				data, err := io.ReadAll(recorder.Body)
+				require.NoError(t, err)
+
+				var got repo.PendingTransferTxResult
+				require.NoError(t, json.Unmarshal(data, &got))
+				require.Equal(t, result, got)
+			},
+		},
+		{
+			name:       "Sender",
+			transferID: pending.ID,
+			username:   sender.Username,
+			role:       util.DepositorRole,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				mockStore.EXPECT().VoidTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:       "AlreadyPosted",
+			transferID: pending.ID,
+			username:   "admin",
+			role:       util.AdminRole,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().
+					VoidTransferTx(gomock.Any(), gomock.Eq(pending.ID)).
+					Times(1).
+					Return(repo.PendingTransferTxResult{}, fmt.Errorf("%w: transfer %d is posted", repo.ErrInvalidTransferStatus, pending.ID))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mockrepo.NewMockStore(ctrl)
+			tc.buildStubs(mockStore)
+			stubTokenNotRevoked(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfers/%d/void", tc.transferID)
+			request, err := http.NewRequest(http.MethodPost, url, http.NoBody)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func randomPendingTransfer(fromAccountID, toAccountID int64) repo.Transfer {
+	transfer := randomTransfer(fromAccountID, toAccountID)
+	expiresAt := transfer.CreatedAt.Add(defaultHoldDuration)
+
+	transfer.Status = repo.TransferStatusPending
+	transfer.ExpiresAt = &expiresAt
+	return transfer
+}
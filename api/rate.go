@@ -0,0 +1,53 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"net/http"
+	"time"
+)
+
+type rateRequest struct {
+	BaseCurrency  string `json:"base_currency" binding:"required,currency"`
+	QuoteCurrency string `json:"quote_currency" binding:"required,currency,nefield=BaseCurrency"`
+	// Rate is a decimal string, so no precision is lost on the way to the database
+	Rate      string    `json:"rate" binding:"required,rate"`
+	ValidFrom time.Time `json:"valid_from"`
+}
+
+type loadRatesRequest struct {
+	Rates []rateRequest `json:"rates" binding:"required,min=1,dive"`
+}
+
+// loadRates stores exchange rates on behalf of an admin. A rate without valid_from applies immediately.
+func (s *Server) loadRates(ctx *gin.Context) {
+	var req loadRatesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	now := time.Now()
+	arg := make([]repo.CreateRateParams, 0, len(req.Rates))
+	for _, rate := range req.Rates {
+		validFrom := rate.ValidFrom
+		if validFrom.IsZero() {
+			validFrom = now
+		}
+
+		arg = append(arg, repo.CreateRateParams{
+			BaseCurrency:  rate.BaseCurrency,
+			QuoteCurrency: rate.QuoteCurrency,
+			Rate:          rate.Rate,
+			ValidFrom:     validFrom,
+		})
+	}
+
+	rates, err := s.store.LoadRatesTx(ctx, arg)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rates)
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	mockrepo "github.com/max-rodziyevsky/go-simple-bank/internal/repo/mock"
+	"github.com/max-rodziyevsky/go-simple-bank/token"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLoadRates(t *testing.T) {
+	admin := util.RandomOwner()
+	validFrom := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	testCases := []struct {
+		name       string
+		rates      []gin.H
+		setupAuth  func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs func(mockStore *mockrepo.MockStore)
+		statusCode int
+	}{
+		{
+			name: "OK",
+			rates: []gin.H{
+				{"base_currency": util.USD, "quote_currency": util.EUR, "rate": "0.92", "valid_from": validFrom},
+				{"base_currency": util.EUR, "quote_currency": util.USD, "rate": "1.087"},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().
+					LoadRatesTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg []repo.CreateRateParams) ([]repo.Rate, error) {
+						require.Len(t, arg, 2)
+						require.Equal(t, "0.92", arg[0].Rate)
+						require.True(t, validFrom.Equal(arg[0].ValidFrom))
+						// a rate without valid_from applies immediately
+						require.WithinDuration(t, time.Now(), arg[1].ValidFrom, time.Second)
+						return []repo.Rate{}, nil
+					})
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "NotAdmin",
+			rates: []gin.H{
+				{"base_currency": util.USD, "quote_currency": util.EUR, "rate": "0.92"},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().LoadRatesTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:  "Empty",
+			rates: []gin.H{},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().LoadRatesTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "InvalidRate",
+			rates: []gin.H{
+				{"base_currency": util.USD, "quote_currency": util.EUR, "rate": "0.0"},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().LoadRatesTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "SameCurrency",
+			rates: []gin.H{
+				{"base_currency": util.USD, "quote_currency": util.USD, "rate": "1"},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().LoadRatesTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "InternalError",
+			rates: []gin.H{
+				{"base_currency": util.USD, "quote_currency": util.EUR, "rate": "0.92"},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().LoadRatesTx(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mockrepo.NewMockStore(ctrl)
+			tc.buildStubs(mockStore)
+			stubTokenNotRevoked(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"rates": tc.rates})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/rates", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.statusCode, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"github.com/max-rodziyevsky/go-simple-bank/token"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"io"
+	"net/http"
+)
+
+type reverseTransferURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// reverseTransferRequest is optional, without an amount the whole remaining amount is reversed
+type reverseTransferRequest struct {
+	Amount int64 `json:"amount" binding:"omitempty,gt=0"`
+}
+
+// reverseTransfer refunds a transfer to its sender. The money is taken back from the destination account,
+// so only its owner, who refunds the sender, or an admin may reverse the transfer.
+func (s *Server) reverseTransfer(ctx *gin.Context) {
+	var uri reverseTransferURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	var req reverseTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Role != util.AdminRole {
+		transfer, err := s.store.GetTransfer(ctx, uri.ID)
+		if err != nil {
+			handleError(ctx, err)
+			return
+		}
+
+		if _, ok := s.ownedAccount(ctx, transfer.ToAccountID); !ok {
+			return
+		}
+	}
+
+	result, err := s.store.ReverseTransferTx(ctx, repo.ReverseTransferTxParams{
+		TransferID: uri.ID,
+		Amount:     req.Amount,
+	})
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	mockrepo "github.com/max-rodziyevsky/go-simple-bank/internal/repo/mock"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReverseTransfer(t *testing.T) {
+	sender, _ := createRandomUser(t)
+	receiver, _ := createRandomUser(t)
+	fromAccount := randomAccount(sender.Username)
+	toAccount := randomAccount(receiver.Username)
+
+	transfer := randomTransfer(fromAccount.ID, toAccount.ID)
+	reversal := randomTransfer(toAccount.ID, fromAccount.ID)
+	reversal.ReversalOf = &transfer.ID
+	result := repo.TransferTxResult{
+		Transfer: reversal,
+		FromEntry: repo.Entry{
+			AccountID:  toAccount.ID,
+			Amount:     -reversal.Amount,
+			TransferID: &reversal.ID,
+			Kind:       repo.EntryKindTransfer,
+			Metadata:   reversal.Metadata,
+		},
+		ToEntry: repo.Entry{
+			AccountID:  fromAccount.ID,
+			Amount:     reversal.ToAmount,
+			TransferID: &reversal.ID,
+			Kind:       repo.EntryKindTransfer,
+			Metadata:   reversal.Metadata,
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		transferID    int64
+		body          gin.H
+		username      string
+		role          string
+		buildStubs    func(mockStore *mockrepo.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "RefundedByReceiver",
+			transferID: transfer.ID,
+			username:   receiver.Username,
+			role:       util.DepositorRole,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				arg := repo.ReverseTransferTxParams{TransferID: transfer.ID}
+
+				mockStore.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				mockStore.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				data, err := io.ReadAll(recorder.Body)
+				require.NoError(t, err)
+
+				var got repo.TransferTxResult
+				require.NoError(t, json.Unmarshal(data, &got))
+				require.Equal(t, result, got)
+			},
+		},
+		{
+			name:       "PartialByAdmin",
+			transferID: transfer.ID,
+			body:       gin.H{"amount": 1},
+			username:   "admin",
+			role:       util.AdminRole,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				arg := repo.ReverseTransferTxParams{
+					TransferID: transfer.ID,
+					Amount:     1,
+				}
+
+				mockStore.EXPECT().GetTransfer(gomock.Any(), gomock.Any()).Times(0)
+				mockStore.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:       "Sender",
+			transferID: transfer.ID,
+			username:   sender.Username,
+			role:       util.DepositorRole,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				mockStore.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:       "TransferNotFound",
+			transferID: transfer.ID,
+			username:   receiver.Username,
+			role:       util.DepositorRole,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(repo.Transfer{}, repo.ErrRecordNotFound)
+				mockStore.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:       "ExceedsTransfer",
+			transferID: transfer.ID,
+			body:       gin.H{"amount": transfer.ToAmount + 1},
+			username:   "admin",
+			role:       util.AdminRole,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().
+					ReverseTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(repo.TransferTxResult{}, fmt.Errorf("%w: %d left to reverse", repo.ErrReversalExceedsTransfer, transfer.ToAmount))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:       "InvalidAmount",
+			transferID: transfer.ID,
+			body:       gin.H{"amount": -1},
+			username:   "admin",
+			role:       util.AdminRole,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:       "InvalidID",
+			transferID: 0,
+			username:   "admin",
+			role:       util.AdminRole,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mockrepo.NewMockStore(ctrl)
+			tc.buildStubs(mockStore)
+			stubTokenNotRevoked(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			var body io.Reader = http.NoBody
+			if tc.body != nil {
+				data, err := json.Marshal(tc.body)
+				require.NoError(t, err)
+				body = bytes.NewReader(data)
+			}
+
+			url := fmt.Sprintf("/transfers/%d/reverse", tc.transferID)
+			request, err := http.NewRequest(http.MethodPost, url, body)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
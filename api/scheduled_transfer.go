@@ -0,0 +1,247 @@
+package api
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"github.com/max-rodziyevsky/go-simple-bank/token"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxRunsPageSize is the number of latest runs returned for a scheduled transfer
+const maxRunsPageSize = 100
+
+var (
+	errScheduledTransferNotOwned = errors.New("scheduled transfer doesn't belong to the authenticated user")
+	errInvalidEndDate            = errors.New("end_date must be after the first run")
+	errSameAccount               = errors.New("from_account_id and to_account_id must be different")
+)
+
+type scheduledTransferURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type createScheduledTransferRequest struct {
+	FromAccountID int64      `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64      `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64      `json:"amount" binding:"required,gt=0"`
+	Currency      string     `json:"currency" binding:"required,currency"`
+	IntervalUnit  string     `json:"interval_unit" binding:"required,oneof=day week month"`
+	IntervalCount int32      `json:"interval_count" binding:"omitempty,min=1,max=366"`
+	StartAt       time.Time  `json:"start_at" binding:"required"`
+	EndDate       *time.Time `json:"end_date"`
+}
+
+// createScheduledTransfer sets up a standing order, the first transfer runs at start_at
+func (s *Server) createScheduledTransfer(ctx *gin.Context) {
+	var req createScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+	if req.FromAccountID == req.ToAccountID {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, errSameAccount))
+		return
+	}
+	if req.EndDate != nil && !req.EndDate.After(req.StartAt) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, errInvalidEndDate))
+		return
+	}
+	if req.IntervalCount == 0 {
+		req.IntervalCount = 1
+	}
+
+	fromAccount, valid := s.validCurrency(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		ctx.JSON(http.StatusForbidden, errorResponse(codeForbidden, errAccountNotOwned))
+		return
+	}
+
+	if _, err := s.store.GetAccount(ctx, req.ToAccountID); err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	scheduled, err := s.store.CreateScheduledTransfer(ctx, repo.CreateScheduledTransferParams{
+		Owner:         authPayload.Username,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		IntervalUnit:  req.IntervalUnit,
+		IntervalCount: req.IntervalCount,
+		NextRunAt:     req.StartAt,
+		EndDate:       req.EndDate,
+	})
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+func (s *Server) getScheduledTransfer(ctx *gin.Context) {
+	var uri scheduledTransferURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	scheduled, ok := s.ownedScheduledTransfer(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+type listScheduledTransfersRequest struct {
+	AfterID int64 `form:"after_id" binding:"min=0"`
+	Limit   int32 `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+func (s *Server) listScheduledTransfers(ctx *gin.Context) {
+	var req listScheduledTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+	limit := pageSizeOrDefault(req.Limit)
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	scheduled, err := s.store.ListScheduledTransfers(ctx, repo.ListScheduledTransfersParams{
+		Owner:    authPayload.Username,
+		AfterID:  req.AfterID,
+		PageSize: limit + 1,
+	})
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	result := page[repo.ScheduledTransfer]{Items: scheduled}
+	if len(scheduled) > int(limit) {
+		result.Items = scheduled[:limit]
+		result.NextCursor = strconv.FormatInt(result.Items[limit-1].ID, 10)
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// updateScheduledTransferRequest replaces the schedule. Active is a pointer, so pausing can be told apart
+// from a missing field
+type updateScheduledTransferRequest struct {
+	Amount        int64      `json:"amount" binding:"required,gt=0"`
+	IntervalUnit  string     `json:"interval_unit" binding:"required,oneof=day week month"`
+	IntervalCount int32      `json:"interval_count" binding:"required,min=1,max=366"`
+	NextRunAt     time.Time  `json:"next_run_at" binding:"required"`
+	EndDate       *time.Time `json:"end_date"`
+	Active        *bool      `json:"active" binding:"required"`
+}
+
+func (s *Server) updateScheduledTransfer(ctx *gin.Context) {
+	var uri scheduledTransferURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	var req updateScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+	if req.EndDate != nil && !req.EndDate.After(req.NextRunAt) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, errInvalidEndDate))
+		return
+	}
+
+	if _, ok := s.ownedScheduledTransfer(ctx, uri.ID); !ok {
+		return
+	}
+
+	scheduled, err := s.store.UpdateScheduledTransfer(ctx, repo.UpdateScheduledTransferParams{
+		Amount:        req.Amount,
+		IntervalUnit:  req.IntervalUnit,
+		IntervalCount: req.IntervalCount,
+		NextRunAt:     req.NextRunAt,
+		EndDate:       req.EndDate,
+		Active:        *req.Active,
+		ID:            uri.ID,
+	})
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+// deleteScheduledTransfer removes the schedule with its runs, transfers it has made are kept
+func (s *Server) deleteScheduledTransfer(ctx *gin.Context) {
+	var uri scheduledTransferURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	if _, ok := s.ownedScheduledTransfer(ctx, uri.ID); !ok {
+		return
+	}
+
+	if err := s.store.DeleteScheduledTransfer(ctx, uri.ID); err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// listScheduledTransferRuns returns the latest runs first, so a failing standing order is easy to spot
+func (s *Server) listScheduledTransferRuns(ctx *gin.Context) {
+	var uri scheduledTransferURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	if _, ok := s.ownedScheduledTransfer(ctx, uri.ID); !ok {
+		return
+	}
+
+	runs, err := s.store.ListScheduledTransferRuns(ctx, repo.ListScheduledTransferRunsParams{
+		ScheduledTransferID: uri.ID,
+		PageSize:            maxRunsPageSize,
+	})
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page[repo.ScheduledTransferRun]{Items: runs})
+}
+
+// ownedScheduledTransfer works as ownedAccount for scheduled transfers
+func (s *Server) ownedScheduledTransfer(ctx *gin.Context, id int64) (repo.ScheduledTransfer, bool) {
+	scheduled, err := s.store.GetScheduledTransfer(ctx, id)
+	if err != nil {
+		handleError(ctx, err)
+		return scheduled, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if scheduled.Owner != authPayload.Username {
+		ctx.JSON(http.StatusForbidden, errorResponse(codeForbidden, errScheduledTransferNotOwned))
+		return scheduled, false
+	}
+
+	return scheduled, true
+}
@@ -0,0 +1,436 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	mockrepo "github.com/max-rodziyevsky/go-simple-bank/internal/repo/mock"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreateScheduledTransfer(t *testing.T) {
+	user, _ := createRandomUser(t)
+	otherUser, _ := createRandomUser(t)
+	fromAccount := randomAccount(user.Username)
+	toAccount := randomAccount(otherUser.Username)
+	startAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	endDate := startAt.AddDate(1, 0, 0)
+
+	scheduled := randomScheduledTransfer(user.Username, fromAccount, toAccount.ID)
+
+	validBody := func() gin.H {
+		return gin.H{
+			"from_account_id": fromAccount.ID,
+			"to_account_id":   toAccount.ID,
+			"amount":          scheduled.Amount,
+			"currency":        fromAccount.Currency,
+			"interval_unit":   repo.IntervalMonth,
+			"start_at":        startAt,
+			"end_date":        endDate,
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		body          func() gin.H
+		username      string
+		buildStubs    func(mockStore *mockrepo.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			body:     validBody,
+			username: user.Username,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				arg := repo.CreateScheduledTransferParams{
+					Owner:         user.Username,
+					FromAccountID: fromAccount.ID,
+					ToAccountID:   toAccount.ID,
+					Amount:        scheduled.Amount,
+					Currency:      fromAccount.Currency,
+					IntervalUnit:  repo.IntervalMonth,
+					IntervalCount: 1,
+					NextRunAt:     startAt,
+					EndDate:       &endDate,
+				}
+
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				mockStore.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Eq(arg)).Times(1).Return(scheduled, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchScheduledTransfer(t, recorder.Body, scheduled)
+			},
+		},
+		{
+			name:     "NotOwner",
+			body:     validBody,
+			username: otherUser.Username,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				mockStore.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "ToAccountNotFound",
+			body:     validBody,
+			username: user.Username,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(repo.Account{}, repo.ErrRecordNotFound)
+				mockStore.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "SameAccount",
+			body: func() gin.H {
+				body := validBody()
+				body["to_account_id"] = fromAccount.ID
+				return body
+			},
+			username: user.Username,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "EndDateBeforeStart",
+			body: func() gin.H {
+				body := validBody()
+				body["end_date"] = startAt.Add(-time.Minute)
+				return body
+			},
+			username: user.Username,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidIntervalUnit",
+			body: func() gin.H {
+				body := validBody()
+				body["interval_unit"] = "year"
+				return body
+			},
+			username: user.Username,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mockrepo.NewMockStore(ctrl)
+			tc.buildStubs(mockStore)
+			stubTokenNotRevoked(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body())
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/scheduled_transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetScheduledTransfer(t *testing.T) {
+	user, _ := createRandomUser(t)
+	otherUser, _ := createRandomUser(t)
+	scheduled := randomScheduledTransfer(user.Username, randomAccount(user.Username), util.RandomInt(1, 1000))
+
+	testCases := []struct {
+		name       string
+		username   string
+		found      bool
+		statusCode int
+	}{
+		{name: "OK", username: user.Username, found: true, statusCode: http.StatusOK},
+		{name: "NotOwner", username: otherUser.Username, found: true, statusCode: http.StatusForbidden},
+		{name: "NotFound", username: user.Username, found: false, statusCode: http.StatusNotFound},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mockrepo.NewMockStore(ctrl)
+			if tc.found {
+				mockStore.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+			} else {
+				mockStore.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(repo.ScheduledTransfer{}, repo.ErrRecordNotFound)
+			}
+			stubTokenNotRevoked(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/scheduled_transfers/%d", scheduled.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.statusCode, recorder.Code)
+			if tc.statusCode == http.StatusOK {
+				requireBodyMatchScheduledTransfer(t, recorder.Body, scheduled)
+			}
+		})
+	}
+}
+
+func TestListScheduledTransfers(t *testing.T) {
+	user, _ := createRandomUser(t)
+	account := randomAccount(user.Username)
+
+	scheduled := make([]repo.ScheduledTransfer, 3)
+	for i := range scheduled {
+		scheduled[i] = randomScheduledTransfer(user.Username, account, util.RandomInt(1, 1000))
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mockrepo.NewMockStore(ctrl)
+	mockStore.EXPECT().
+		ListScheduledTransfers(gomock.Any(), gomock.Eq(repo.ListScheduledTransfersParams{
+			Owner:    user.Username,
+			AfterID:  7,
+			PageSize: 3,
+		})).
+		Times(1).
+		Return(scheduled, nil)
+	stubTokenNotRevoked(mockStore)
+
+	server := newTestServer(t, mockStore)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/scheduled_transfers?after_id=7&limit=2", nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var got page[repo.ScheduledTransfer]
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	require.Equal(t, scheduled[:2], got.Items)
+	require.Equal(t, fmt.Sprint(scheduled[1].ID), got.NextCursor)
+}
+
+func TestUpdateScheduledTransfer(t *testing.T) {
+	user, _ := createRandomUser(t)
+	scheduled := randomScheduledTransfer(user.Username, randomAccount(user.Username), util.RandomInt(1, 1000))
+
+	paused := scheduled
+	paused.Active = false
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(mockStore *mockrepo.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Pause",
+			body: gin.H{
+				"amount":         scheduled.Amount,
+				"interval_unit":  scheduled.IntervalUnit,
+				"interval_count": scheduled.IntervalCount,
+				"next_run_at":    scheduled.NextRunAt,
+				"active":         false,
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				arg := repo.UpdateScheduledTransferParams{
+					Amount:        scheduled.Amount,
+					IntervalUnit:  scheduled.IntervalUnit,
+					IntervalCount: scheduled.IntervalCount,
+					NextRunAt:     scheduled.NextRunAt,
+					Active:        false,
+					ID:            scheduled.ID,
+				}
+
+				mockStore.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				mockStore.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Eq(arg)).Times(1).Return(paused, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchScheduledTransfer(t, recorder.Body, paused)
+			},
+		},
+		{
+			name: "MissingActive",
+			body: gin.H{
+				"amount":         scheduled.Amount,
+				"interval_unit":  scheduled.IntervalUnit,
+				"interval_count": scheduled.IntervalCount,
+				"next_run_at":    scheduled.NextRunAt,
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mockrepo.NewMockStore(ctrl)
+			tc.buildStubs(mockStore)
+			stubTokenNotRevoked(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/scheduled_transfers/%d", scheduled.ID)
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestDeleteScheduledTransfer(t *testing.T) {
+	user, _ := createRandomUser(t)
+	scheduled := randomScheduledTransfer(user.Username, randomAccount(user.Username), util.RandomInt(1, 1000))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mockrepo.NewMockStore(ctrl)
+	mockStore.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+	mockStore.EXPECT().DeleteScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(nil)
+	stubTokenNotRevoked(mockStore)
+
+	server := newTestServer(t, mockStore)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/scheduled_transfers/%d", scheduled.ID)
+	request, err := http.NewRequest(http.MethodDelete, url, nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestListScheduledTransferRuns(t *testing.T) {
+	user, _ := createRandomUser(t)
+	scheduled := randomScheduledTransfer(user.Username, randomAccount(user.Username), util.RandomInt(1, 1000))
+
+	transferID := util.RandomInt(1, 1000)
+	runs := []repo.ScheduledTransferRun{
+		{ID: 2, ScheduledTransferID: scheduled.ID, TransferID: &transferID},
+		{ID: 1, ScheduledTransferID: scheduled.ID, Error: repo.ErrInsufficientFunds.Error()},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mockrepo.NewMockStore(ctrl)
+	mockStore.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+	mockStore.EXPECT().
+		ListScheduledTransferRuns(gomock.Any(), gomock.Eq(repo.ListScheduledTransferRunsParams{
+			ScheduledTransferID: scheduled.ID,
+			PageSize:            maxRunsPageSize,
+		})).
+		Times(1).
+		Return(runs, nil)
+	stubTokenNotRevoked(mockStore)
+
+	server := newTestServer(t, mockStore)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/scheduled_transfers/%d/runs", scheduled.ID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var got page[repo.ScheduledTransferRun]
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	require.Equal(t, runs, got.Items)
+}
+
+func randomScheduledTransfer(owner string, fromAccount repo.Account, toAccountID int64) repo.ScheduledTransfer {
+	startAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	return repo.ScheduledTransfer{
+		ID:            util.RandomInt(1, 1000),
+		Owner:         owner,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccountID,
+		Amount:        util.RandomMoney(),
+		Currency:      fromAccount.Currency,
+		IntervalUnit:  repo.IntervalMonth,
+		IntervalCount: 1,
+		NextRunAt:     startAt,
+		Active:        true,
+		CreatedAt:     time.Now().UTC().Truncate(time.Second),
+		StartAt:       startAt,
+	}
+}
+
+func requireBodyMatchScheduledTransfer(t *testing.T, body *bytes.Buffer, scheduled repo.ScheduledTransfer) {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var got repo.ScheduledTransfer
+	require.NoError(t, json.Unmarshal(data, &got))
+	require.Equal(t, scheduled, got)
+}
@@ -1,50 +1,130 @@
 package api
 
 import (
+	"context"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"github.com/max-rodziyevsky/go-simple-bank/configs"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/currency"
 	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"github.com/max-rodziyevsky/go-simple-bank/token"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"net"
+	"net/http"
 )
 
 type Server struct {
-	store  repo.Store
-	router *gin.Engine
+	config     configs.Config
+	store      repo.Store
+	tokenMaker token.Maker
+	currencies *currency.Registry
+	router     *gin.Engine
+	httpServer *http.Server
 }
 
-func NewServer(store repo.Store) *Server {
-	server := &Server{store: store}
+func NewServer(config configs.Config, store repo.Store, currencies *currency.Registry) (*Server, error) {
+	tokenMaker, err := token.NewMaker(config.TokenType, config.TokenSymmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("can't create token maker: %w", err)
+	}
+
+	server := &Server{
+		config:     config,
+		store:      store,
+		tokenMaker: tokenMaker,
+		currencies: currencies,
+	}
 	router := gin.Default()
-	err := router.SetTrustedProxies(nil)
+	err = router.SetTrustedProxies(nil)
 	if err != nil {
-		return nil
+		return nil, err
 	}
+	router.Use(metricsMiddleware())
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
-		err := v.RegisterValidation("currency", validCurrency)
+		err := v.RegisterValidation("currency", currencyValidator(currencies))
 		if err != nil {
-			return nil
+			return nil, err
+		}
+
+		err = v.RegisterValidation("rate", validRate)
+		if err != nil {
+			return nil, err
 		}
 	}
 
+	router.GET("/healthz", server.healthz)
+	router.GET("/readyz", server.readyz)
+
 	router.POST("/users", server.createUser)
+	router.POST("/users/login", server.loginUser)
+	router.POST("/tokens/renew_access", server.renewAccessToken)
+	router.GET("/currencies", server.listCurrencies)
+
+	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.store))
 
-	router.POST("/accounts", server.createAccount)
-	router.GET("/accounts/:id", server.getAccount)
-	router.GET("/accounts", server.listAccounts)
-	router.PUT("/accounts", server.updateAccount)
-	router.DELETE("accounts/:id", server.deleteAccount)
+	authRoutes.POST("/users/logout", server.logoutUser)
 
-	router.POST("/transfers", server.createTransfer)
+	authRoutes.POST("/accounts", server.createAccount)
+	authRoutes.GET("/accounts/:id", server.getAccount)
+	authRoutes.GET("/accounts", server.listAccounts)
+	authRoutes.DELETE("/accounts/:id", server.deleteAccount)
+	authRoutes.GET("/accounts/:id/transfers", server.listAccountTransfers)
+	authRoutes.GET("/accounts/:id/entries", server.listAccountEntries)
+	authRoutes.GET("/accounts/:id/statement", server.getAccountStatement)
+	authRoutes.POST("/accounts/:id/withdrawals", server.createWithdrawal)
+	authRoutes.POST("/accounts/:id/close", server.closeAccount)
+
+	authRoutes.POST("/transfers", server.createTransfer)
+	authRoutes.POST("/transfers/:id/reverse", server.reverseTransfer)
+	authRoutes.POST("/transfers/:id/capture", server.captureTransfer)
+	authRoutes.POST("/transfers/:id/void", server.voidTransfer)
+
+	authRoutes.POST("/scheduled_transfers", server.createScheduledTransfer)
+	authRoutes.GET("/scheduled_transfers/:id", server.getScheduledTransfer)
+	authRoutes.GET("/scheduled_transfers", server.listScheduledTransfers)
+	authRoutes.PUT("/scheduled_transfers/:id", server.updateScheduledTransfer)
+	authRoutes.DELETE("/scheduled_transfers/:id", server.deleteScheduledTransfer)
+	authRoutes.GET("/scheduled_transfers/:id/runs", server.listScheduledTransferRuns)
+
+	adminRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.store), roleMiddleware(util.AdminRole))
+
+	adminRoutes.DELETE("/sessions/:id", server.revokeSession)
+	adminRoutes.POST("/rates", server.loadRates)
+	adminRoutes.PUT("/accounts", server.updateAccount)
+	adminRoutes.PUT("/accounts/:id/overdraft_limit", server.updateOverdraftLimit)
+	adminRoutes.POST("/accounts/:id/deposits", server.createDeposit)
+	adminRoutes.POST("/accounts/:id/freeze", server.freezeAccount)
+	adminRoutes.POST("/accounts/:id/unfreeze", server.unfreezeAccount)
+	adminRoutes.GET("/users/:username/transfer_limits", server.listUserTransferLimits)
+	adminRoutes.PUT("/users/:username/transfer_limits/:currency", server.setUserTransferLimit)
+	adminRoutes.DELETE("/users/:username/transfer_limits/:currency", server.deleteUserTransferLimit)
+	adminRoutes.PUT("/transfer_limits/:currency", server.setTransferLimit)
 
 	server.router = router
-	return server
+	server.httpServer = &http.Server{
+		Handler:        router,
+		ReadTimeout:    config.ReadTimeout,
+		WriteTimeout:   config.WriteTimeout,
+		IdleTimeout:    config.IdleTimeout,
+		MaxHeaderBytes: config.MaxHeaderBytes,
+	}
+	return server, nil
 }
 
+// Start serves requests on the address until Shutdown is called, it returns http.ErrServerClosed then
 func (s *Server) Start(address string) error {
-	return s.router.Run(address)
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	return s.httpServer.Serve(listener)
 }
 
-func errorResponse(err error) gin.H {
-	return gin.H{"error": err.Error()}
+// Shutdown stops accepting connections and waits for the requests in flight until ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
 }
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockrepo "github.com/max-rodziyevsky/go-simple-bank/internal/repo/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServer_Shutdown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockrepo.NewMockStore(ctrl))
+
+	started := make(chan error, 1)
+	go func() {
+		started <- server.Start("127.0.0.1:0")
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, server.Shutdown(ctx))
+	require.ErrorIs(t, <-started, http.ErrServerClosed)
+}
+
+func TestServer_ShutdownDrainsRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockrepo.NewMockStore(ctrl))
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	server.router.GET("/slow", func(ctx *gin.Context) {
+		close(entered)
+		<-release
+		ctx.String(http.StatusOK, "done")
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.httpServer.Serve(listener)
+	}()
+
+	type result struct {
+		status int
+		body   string
+		err    error
+	}
+	responses := make(chan result, 1)
+	go func() {
+		response, err := http.Get(fmt.Sprintf("http://%s/slow", listener.Addr()))
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		defer response.Body.Close()
+
+		body, err := io.ReadAll(response.Body)
+		responses <- result{status: response.StatusCode, body: string(body), err: err}
+	}()
+	<-entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- server.Shutdown(ctx)
+	}()
+
+	// the server stops accepting connections, but waits for the request in flight
+	require.ErrorIs(t, <-served, http.ErrServerClosed)
+	select {
+	case err := <-shutdown:
+		t.Fatalf("shutdown returned before the request completed: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	require.NoError(t, <-shutdown)
+
+	got := <-responses
+	require.NoError(t, got.err)
+	require.Equal(t, http.StatusOK, got.status)
+	require.Equal(t, "done", got.body)
+}
+
+func TestNewServer_HTTPServerConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockrepo.NewMockStore(ctrl))
+	require.Equal(t, server.router, server.httpServer.Handler)
+	require.Equal(t, server.config.ReadTimeout, server.httpServer.ReadTimeout)
+	require.Equal(t, server.config.WriteTimeout, server.httpServer.WriteTimeout)
+	require.Equal(t, server.config.IdleTimeout, server.httpServer.IdleTimeout)
+	require.Equal(t, server.config.MaxHeaderBytes, server.httpServer.MaxHeaderBytes)
+}
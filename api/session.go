@@ -0,0 +1,39 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"net/http"
+)
+
+type revokeSessionRequest struct {
+	ID string `uri:"id" binding:"required,uuid"`
+}
+
+// revokeSession blocks a session on behalf of an admin and revokes its refresh token
+func (s *Server) revokeSession(ctx *gin.Context) {
+	var req revokeSessionRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	session, err := s.store.BlockSession(ctx, uuid.MustParse(req.ID))
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	err = s.store.RevokeToken(ctx, repo.RevokeTokenParams{
+		ID:        session.ID,
+		Username:  session.Username,
+		ExpiresAt: session.ExpiresAt,
+	})
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
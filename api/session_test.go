@@ -0,0 +1,132 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	mockrepo "github.com/max-rodziyevsky/go-simple-bank/internal/repo/mock"
+	"github.com/max-rodziyevsky/go-simple-bank/token"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRevokeSession(t *testing.T) {
+	session := repo.Session{
+		ID:        uuid.New(),
+		Username:  util.RandomOwner(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	admin := util.RandomOwner()
+
+	testCases := []struct {
+		name       string
+		sessionID  string
+		setupAuth  func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs func(mockStore *mockrepo.MockStore)
+		statusCode int
+	}{
+		{
+			name:      "OK",
+			sessionID: session.ID.String(),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				arg := repo.RevokeTokenParams{
+					ID:        session.ID,
+					Username:  session.Username,
+					ExpiresAt: session.ExpiresAt,
+				}
+
+				mockStore.EXPECT().BlockSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				mockStore.EXPECT().RevokeToken(gomock.Any(), gomock.Eq(arg)).Times(1).Return(nil)
+			},
+			statusCode: http.StatusNoContent,
+		},
+		{
+			name:      "NotAdmin",
+			sessionID: session.ID.String(),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, session.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().BlockSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:      "NoAuthorization",
+			sessionID: session.ID.String(),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().BlockSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:      "InvalidID",
+			sessionID: "invalid",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().BlockSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:      "NotFound",
+			sessionID: session.ID.String(),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().BlockSession(gomock.Any(), gomock.Any()).Times(1).Return(repo.Session{}, repo.ErrRecordNotFound)
+				mockStore.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:      "InternalError",
+			sessionID: session.ID.String(),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().BlockSession(gomock.Any(), gomock.Any()).Times(1).Return(repo.Session{}, sql.ErrConnDone)
+			},
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mockrepo.NewMockStore(ctrl)
+			tc.buildStubs(mockStore)
+			stubTokenNotRevoked(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/sessions/%s", tc.sessionID)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.statusCode, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,402 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	statementFormatCSV  = "csv"
+	statementFormatJSON = "json"
+	statementFormatOFX  = "ofx"
+
+	// statementPageSize bounds the memory used by a statement, lines are streamed page by page
+	statementPageSize = 500
+
+	ofxTimeFormat = "20060102150405"
+)
+
+type statementRequest struct {
+	From   time.Time `form:"from" binding:"required"`
+	To     time.Time `form:"to" binding:"required,gtfield=From"`
+	Format string    `form:"format" binding:"omitempty,oneof=csv json ofx"`
+}
+
+// statement describes the period, lines are passed to the writer one by one
+type statement struct {
+	Account        repo.Account
+	From           time.Time
+	To             time.Time
+	OpeningBalance int64
+	// Exponent is the number of minor unit digits of the account currency
+	Exponent int32
+}
+
+// statementWriter renders a statement in one of the export formats
+type statementWriter interface {
+	begin(st statement) error
+	line(line repo.ListStatementLinesRow, balance int64) error
+	end(closingBalance int64) error
+	flush() error
+}
+
+// getAccountStatement streams the opening balance, every entry of the period with its counterparty and the closing balance.
+// Entries are read page by page, so a long period doesn't have to fit in memory.
+func (s *Server) getAccountStatement(ctx *gin.Context) {
+	var uri accountURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	var req statementRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+	if len(req.Format) == 0 {
+		req.Format = statementFormatCSV
+	}
+
+	account, ok := s.ownedAccount(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	opening, err := s.store.GetAccountBalanceAt(ctx, repo.GetAccountBalanceAtParams{
+		AccountID: account.ID,
+		Before:    req.From,
+	})
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	arg := repo.ListStatementLinesParams{
+		AccountID: account.ID,
+		FromTime:  req.From,
+		ToTime:    req.To,
+		PageSize:  statementPageSize,
+	}
+
+	// the first page is read before the response starts, so most errors still get a proper status
+	lines, err := s.store.ListStatementLines(ctx, arg)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	st := statement{
+		Account:        account,
+		From:           req.From,
+		To:             req.To,
+		OpeningBalance: opening,
+		Exponent:       s.currencies.Exponent(account.Currency),
+	}
+
+	filename := fmt.Sprintf("statement-%d-%s-%s.%s", account.ID, req.From.Format("20060102"), req.To.Format("20060102"), req.Format)
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	var writer statementWriter
+	switch req.Format {
+	case statementFormatJSON:
+		ctx.Header("Content-Type", "application/json; charset=utf-8")
+		writer = &jsonStatementWriter{w: ctx.Writer}
+	case statementFormatOFX:
+		ctx.Header("Content-Type", "application/x-ofx")
+		writer = &ofxStatementWriter{w: ctx.Writer}
+	default:
+		ctx.Header("Content-Type", "text/csv; charset=utf-8")
+		writer = &csvStatementWriter{w: csv.NewWriter(ctx.Writer)}
+	}
+	ctx.Status(http.StatusOK)
+
+	// the status is already sent, so a failure can only cut the statement short and get logged
+	if err = streamStatement(ctx, s.store, writer, st, arg, lines, s.config.WriteTimeout); err != nil {
+		_ = ctx.Error(err)
+	}
+}
+
+func streamStatement(
+	ctx *gin.Context,
+	store repo.Store,
+	writer statementWriter,
+	st statement,
+	arg repo.ListStatementLinesParams,
+	lines []repo.ListStatementLinesRow,
+	pageTimeout time.Duration,
+) error {
+	// the write timeout of the server would cut a long statement short, so every page gets its own deadline
+	controller := http.NewResponseController(ctx.Writer)
+	extendDeadline := func() error {
+		if pageTimeout <= 0 {
+			return nil
+		}
+		err := controller.SetWriteDeadline(time.Now().Add(pageTimeout))
+		if errors.Is(err, http.ErrNotSupported) {
+			return nil
+		}
+		return err
+	}
+
+	if err := writer.begin(st); err != nil {
+		return err
+	}
+
+	balance := st.OpeningBalance
+	for {
+		if err := extendDeadline(); err != nil {
+			return err
+		}
+		for _, line := range lines {
+			balance += line.Amount
+			if err := writer.line(line, balance); err != nil {
+				return err
+			}
+		}
+		if err := writer.flush(); err != nil {
+			return err
+		}
+		ctx.Writer.Flush()
+
+		if len(lines) < int(arg.PageSize) {
+			break
+		}
+
+		last := lines[len(lines)-1]
+		arg.AfterCreatedAt = last.CreatedAt
+		arg.AfterID = last.ID
+
+		var err error
+		lines, err = store.ListStatementLines(ctx, arg)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := writer.end(balance); err != nil {
+		return err
+	}
+	return writer.flush()
+}
+
+// formatAmount renders minor units as a decimal number of major units, e.g. -5 with exponent 2 is -0.05
+func formatAmount(amount int64, exponent int32) string {
+	if exponent <= 0 {
+		return strconv.FormatInt(amount, 10)
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	if pad := int(exponent) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+
+	point := len(digits) - int(exponent)
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// csvStatementWriter writes one record per line with the opening and closing balance as the first and last records.
+// The type of a line is the kind of its entry. Amounts are in minor units, as everywhere in the API.
+type csvStatementWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvStatementWriter) begin(st statement) error {
+	if err := c.w.Write([]string{"type", "date", "entry_id", "transfer_id", "counterparty_account_id", "counterparty_owner", "description", "amount", "balance"}); err != nil {
+		return err
+	}
+	return c.w.Write([]string{"opening", st.From.Format(time.RFC3339), "", "", "", "", "", "", strconv.FormatInt(st.OpeningBalance, 10)})
+}
+
+func (c *csvStatementWriter) line(line repo.ListStatementLinesRow, balance int64) error {
+	transferID, counterpartyID := "", ""
+	if line.TransferID != nil {
+		transferID = strconv.FormatInt(*line.TransferID, 10)
+		counterpartyID = strconv.FormatInt(line.CounterpartyAccountID, 10)
+	}
+
+	return c.w.Write([]string{
+		line.Kind,
+		line.CreatedAt.Format(time.RFC3339),
+		strconv.FormatInt(line.ID, 10),
+		transferID,
+		counterpartyID,
+		csvText(line.CounterpartyOwner),
+		csvText(line.Description),
+		strconv.FormatInt(line.Amount, 10),
+		strconv.FormatInt(balance, 10),
+	})
+}
+
+// csvFormulaPrefixes make spreadsheets read a cell as a formula
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// csvText neutralizes free text written by customers: a value which a spreadsheet would run
+// as a formula, like =HYPERLINK(...), is prefixed with a quote so it's shown as text
+func csvText(value string) string {
+	if len(value) > 0 && strings.ContainsRune(csvFormulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func (c *csvStatementWriter) end(closingBalance int64) error {
+	return c.w.Write([]string{"closing", "", "", "", "", "", "", "", strconv.FormatInt(closingBalance, 10)})
+}
+
+func (c *csvStatementWriter) flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonStatementWriter writes a single JSON object, lines are encoded one by one into its lines array
+type jsonStatementWriter struct {
+	w         io.Writer
+	lineCount int
+}
+
+type jsonStatementLine struct {
+	repo.ListStatementLinesRow
+	Balance int64 `json:"balance"`
+}
+
+func (j *jsonStatementWriter) begin(st statement) error {
+	header, err := json.Marshal(struct {
+		AccountID      int64     `json:"account_id"`
+		Currency       string    `json:"currency"`
+		From           time.Time `json:"from"`
+		To             time.Time `json:"to"`
+		OpeningBalance int64     `json:"opening_balance"`
+	}{st.Account.ID, st.Account.Currency, st.From, st.To, st.OpeningBalance})
+	if err != nil {
+		return err
+	}
+
+	// reopen the header object to append the lines to it
+	_, err = fmt.Fprintf(j.w, `%s,"lines":[`, header[:len(header)-1])
+	return err
+}
+
+func (j *jsonStatementWriter) line(line repo.ListStatementLinesRow, balance int64) error {
+	data, err := json.Marshal(jsonStatementLine{line, balance})
+	if err != nil {
+		return err
+	}
+
+	if j.lineCount > 0 {
+		if _, err = io.WriteString(j.w, ","); err != nil {
+			return err
+		}
+	}
+	j.lineCount++
+
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonStatementWriter) end(closingBalance int64) error {
+	_, err := fmt.Fprintf(j.w, `],"closing_balance":%d}`, closingBalance)
+	return err
+}
+
+func (j *jsonStatementWriter) flush() error {
+	return nil
+}
+
+// ofxStatementWriter writes an OFX 2.2 bank statement. OFX has no opening balance element,
+// so only the closing balance is reported as the ledger balance.
+type ofxStatementWriter struct {
+	w        io.Writer
+	exponent int32
+	to       time.Time
+}
+
+// ofxTransactionTypes maps entry kinds to OFX transaction types, adjustments are credits or debits by their sign
+var ofxTransactionTypes = map[string]string{
+	repo.EntryKindTransfer:   "XFER",
+	repo.EntryKindDeposit:    "DEP",
+	repo.EntryKindWithdrawal: "CASH",
+}
+
+type ofxTransaction struct {
+	XMLName xml.Name `xml:"STMTTRN"`
+	Type    string   `xml:"TRNTYPE"`
+	Posted  string   `xml:"DTPOSTED"`
+	Amount  string   `xml:"TRNAMT"`
+	ID      int64    `xml:"FITID"`
+	Name    string   `xml:"NAME,omitempty"`
+	Memo    string   `xml:"MEMO,omitempty"`
+}
+
+func (o *ofxStatementWriter) begin(st statement) error {
+	o.exponent = st.Exponent
+	o.to = st.To
+
+	_, err := fmt.Fprintf(o.w, `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX><BANKMSGSRSV1><STMTTRNRS><TRNUID>0</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>
+<STMTRS><CURDEF>%s</CURDEF><BANKACCTFROM><BANKID>go-simple-bank</BANKID><ACCTID>%d</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>
+<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>
+`, st.Account.Currency, st.Account.ID, st.From.UTC().Format(ofxTimeFormat), st.To.UTC().Format(ofxTimeFormat))
+	return err
+}
+
+func (o *ofxStatementWriter) line(line repo.ListStatementLinesRow, _ int64) error {
+	transaction := ofxTransaction{
+		Type:   "CREDIT",
+		Posted: line.CreatedAt.UTC().Format(ofxTimeFormat),
+		Amount: formatAmount(line.Amount, o.exponent),
+		ID:     line.ID,
+		Name:   line.CounterpartyOwner,
+	}
+	if line.Amount < 0 {
+		transaction.Type = "DEBIT"
+	}
+	if trnType, ok := ofxTransactionTypes[line.Kind]; ok {
+		transaction.Type = trnType
+	}
+
+	transaction.Memo = line.Kind
+	if line.TransferID != nil {
+		transaction.Memo = fmt.Sprintf("transfer %d, account %d", *line.TransferID, line.CounterpartyAccountID)
+	}
+	if len(line.Description) > 0 {
+		transaction.Memo = line.Description
+	}
+
+	data, err := xml.Marshal(transaction)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(o.w, "%s\n", data)
+	return err
+}
+
+func (o *ofxStatementWriter) end(closingBalance int64) error {
+	_, err := fmt.Fprintf(o.w, `</BANKTRANLIST>
+<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>
+`, formatAmount(closingBalance, o.exponent), o.to.UTC().Format(ofxTimeFormat))
+	return err
+}
+
+func (o *ofxStatementWriter) flush() error {
+	return nil
+}
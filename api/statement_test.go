@@ -0,0 +1,407 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	mockrepo "github.com/max-rodziyevsky/go-simple-bank/internal/repo/mock"
+	"github.com/max-rodziyevsky/go-simple-bank/token"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetAccountStatement(t *testing.T) {
+	user, _ := createRandomUser(t)
+	otherUser, _ := createRandomUser(t)
+	account := randomAccount(user.Username)
+	account.Currency = util.USD
+
+	from := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+	opening := int64(1000)
+
+	// a full first page makes the statement read the next one
+	firstPage := make([]repo.ListStatementLinesRow, statementPageSize)
+	for i := range firstPage {
+		firstPage[i] = randomStatementLine(int64(i+1), from.Add(time.Duration(i)*time.Minute))
+	}
+	lastPage := []repo.ListStatementLinesRow{randomStatementLine(statementPageSize+1, to.Add(-time.Minute))}
+
+	closing := opening
+	for _, line := range append(firstPage, lastPage...) {
+		closing += line.Amount
+	}
+
+	query := func(format string) url.Values {
+		return url.Values{
+			"from":   {from.Format(time.RFC3339)},
+			"to":     {to.Format(time.RFC3339)},
+			"format": {format},
+		}
+	}
+
+	buildStatementStubs := func(mockStore *mockrepo.MockStore) {
+		arg := repo.ListStatementLinesParams{
+			AccountID: account.ID,
+			FromTime:  from,
+			ToTime:    to,
+			PageSize:  statementPageSize,
+		}
+		nextArg := arg
+		nextArg.AfterCreatedAt = firstPage[statementPageSize-1].CreatedAt
+		nextArg.AfterID = firstPage[statementPageSize-1].ID
+
+		balanceArg := repo.GetAccountBalanceAtParams{
+			AccountID: account.ID,
+			Before:    from,
+		}
+
+		mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+		mockStore.EXPECT().GetAccountBalanceAt(gomock.Any(), gomock.Eq(balanceArg)).Times(1).Return(opening, nil)
+		gomock.InOrder(
+			mockStore.EXPECT().ListStatementLines(gomock.Any(), gomock.Eq(arg)).Times(1).Return(firstPage, nil),
+			mockStore.EXPECT().ListStatementLines(gomock.Any(), gomock.Eq(nextArg)).Times(1).Return(lastPage, nil),
+		)
+	}
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(mockStore *mockrepo.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "CSV",
+			query: query(statementFormatCSV),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: buildStatementStubs,
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Header().Get("Content-Type"), "text/csv")
+
+				records, err := csv.NewReader(recorder.Body).ReadAll()
+				require.NoError(t, err)
+				// header, opening, lines and closing
+				require.Len(t, records, statementPageSize+4)
+				require.Equal(t, []string{"opening", from.Format(time.RFC3339), "", "", "", "", "", "", fmt.Sprint(opening)}, records[1])
+				require.Equal(t, repo.EntryKindTransfer, records[2][0])
+				require.Equal(t, firstPage[0].Description, records[2][6])
+				require.Equal(t, fmt.Sprint(opening+firstPage[0].Amount), records[2][8])
+				require.Equal(t, fmt.Sprint(closing), records[len(records)-1][8])
+			},
+		},
+		{
+			name:  "JSON",
+			query: query(statementFormatJSON),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: buildStatementStubs,
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got struct {
+					AccountID      int64               `json:"account_id"`
+					OpeningBalance int64               `json:"opening_balance"`
+					Lines          []jsonStatementLine `json:"lines"`
+					ClosingBalance int64               `json:"closing_balance"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, account.ID, got.AccountID)
+				require.Equal(t, opening, got.OpeningBalance)
+				require.Len(t, got.Lines, statementPageSize+1)
+				require.Equal(t, firstPage[0], got.Lines[0].ListStatementLinesRow)
+				require.Equal(t, closing, got.Lines[statementPageSize].Balance)
+				require.Equal(t, closing, got.ClosingBalance)
+			},
+		},
+		{
+			name:  "OFX",
+			query: query(statementFormatOFX),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: buildStatementStubs,
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				body := recorder.Body.String()
+				require.Equal(t, statementPageSize+1, strings.Count(body, "<STMTTRN>"))
+				require.Contains(t, body, "<CURDEF>USD</CURDEF>")
+				require.Contains(t, body, "<TRNTYPE>XFER</TRNTYPE>")
+				require.Contains(t, body, fmt.Sprintf("<MEMO>%s</MEMO>", firstPage[0].Description))
+				require.Contains(t, body, fmt.Sprintf("<BALAMT>%s</BALAMT>", formatAmount(closing, 2)))
+			},
+		},
+		{
+			name:  "MissingPeriod",
+			query: url.Values{"format": {statementFormatCSV}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().ListStatementLines(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidFormat",
+			query: query("pdf"),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().ListStatementLines(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "NotOwner",
+			query: query(statementFormatCSV),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, otherUser.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				mockStore.EXPECT().ListStatementLines(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "OpeningBalanceError",
+			query: query(statementFormatCSV),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				mockStore.EXPECT().GetAccountBalanceAt(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), sql.ErrConnDone)
+				mockStore.EXPECT().ListStatementLines(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:  "ListStatementLinesError",
+			query: query(statementFormatCSV),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				mockStore.EXPECT().GetAccountBalanceAt(gomock.Any(), gomock.Any()).Times(1).Return(opening, nil)
+				mockStore.EXPECT().ListStatementLines(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mockrepo.NewMockStore(ctrl)
+			tc.buildStubs(mockStore)
+			stubTokenNotRevoked(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/statement?%s", account.ID, tc.query.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetAccountStatement_LongerThanWriteTimeout(t *testing.T) {
+	user, _ := createRandomUser(t)
+	account := randomAccount(user.Username)
+
+	from := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+	writeTimeout := 300 * time.Millisecond
+
+	// every page takes most of the write timeout, the whole statement takes more than twice as long
+	pages := make([][]repo.ListStatementLinesRow, 3)
+	for i := range pages {
+		size := statementPageSize
+		if i == len(pages)-1 {
+			size = 1
+		}
+		for j := 0; j < size; j++ {
+			id := int64(i*statementPageSize + j + 1)
+			pages[i] = append(pages[i], randomStatementLine(id, from.Add(time.Duration(id)*time.Minute)))
+		}
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mockrepo.NewMockStore(ctrl)
+	stubTokenNotRevoked(mockStore)
+	mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+	mockStore.EXPECT().GetAccountBalanceAt(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+
+	page := 0
+	mockStore.EXPECT().
+		ListStatementLines(gomock.Any(), gomock.Any()).
+		Times(len(pages)).
+		DoAndReturn(func(_ context.Context, _ repo.ListStatementLinesParams) ([]repo.ListStatementLinesRow, error) {
+			time.Sleep(writeTimeout * 2 / 3)
+			page++
+			return pages[page-1], nil
+		})
+
+	server := newTestServer(t, mockStore)
+	server.config.WriteTimeout = writeTimeout
+	server.httpServer.WriteTimeout = writeTimeout
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = server.httpServer.Serve(listener)
+	}()
+	defer server.httpServer.Close()
+
+	query := url.Values{"from": {from.Format(time.RFC3339)}, "to": {to.Format(time.RFC3339)}}
+	url := fmt.Sprintf("http://%s/accounts/%d/statement?%s", listener.Addr(), account.ID, query.Encode())
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	records, err := csv.NewReader(response.Body).ReadAll()
+	require.NoError(t, err)
+	// header, opening, lines and closing
+	require.Len(t, records, 2*statementPageSize+1+3)
+	require.Equal(t, "closing", records[len(records)-1][0])
+}
+
+func TestCSVStatementWriter_NeutralizesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	writer := &csvStatementWriter{w: csv.NewWriter(&buf)}
+
+	line := randomStatementLine(1, time.Now())
+	line.CounterpartyOwner = "@SUM(A1:A9)"
+	line.Description = `=HYPERLINK("http://example.com","refund")`
+	require.NoError(t, writer.line(line, line.Amount))
+	require.NoError(t, writer.flush())
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, "'"+line.CounterpartyOwner, records[0][5])
+	require.Equal(t, "'"+line.Description, records[0][6])
+}
+
+func TestOFXStatementWriter_EntryKinds(t *testing.T) {
+	testCases := []struct {
+		kind     string
+		amount   int64
+		wantType string
+	}{
+		{kind: repo.EntryKindDeposit, amount: 100, wantType: "DEP"},
+		{kind: repo.EntryKindWithdrawal, amount: -100, wantType: "CASH"},
+		{kind: repo.EntryKindAdjustment, amount: 100, wantType: "CREDIT"},
+		{kind: repo.EntryKindAdjustment, amount: -100, wantType: "DEBIT"},
+	}
+
+	for _, tc := range testCases {
+		var buf bytes.Buffer
+		writer := &ofxStatementWriter{w: &buf, exponent: 2}
+
+		line := repo.ListStatementLinesRow{ID: 1, Amount: tc.amount, Kind: tc.kind, CreatedAt: time.Now()}
+		require.NoError(t, writer.line(line, tc.amount))
+
+		// entries which aren't transfers have no counterparty, the kind tells what they are
+		require.Contains(t, buf.String(), fmt.Sprintf("<TRNTYPE>%s</TRNTYPE>", tc.wantType))
+		require.Contains(t, buf.String(), fmt.Sprintf("<MEMO>%s</MEMO>", tc.kind))
+	}
+}
+
+func TestCSVText(t *testing.T) {
+	testCases := []struct {
+		value string
+		want  string
+	}{
+		{value: "=1+1", want: "'=1+1"},
+		{value: "+1", want: "'+1"},
+		{value: "-1", want: "'-1"},
+		{value: "@cmd", want: "'@cmd"},
+		{value: "\tx", want: "'\tx"},
+		{value: "\rx", want: "'\rx"},
+		{value: "rent for May", want: "rent for May"},
+		{value: "", want: ""},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.want, csvText(tc.value))
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	testCases := []struct {
+		amount   int64
+		exponent int32
+		want     string
+	}{
+		{amount: 12345, exponent: 2, want: "123.45"},
+		{amount: -5, exponent: 2, want: "-0.05"},
+		{amount: 0, exponent: 2, want: "0.00"},
+		{amount: 1000, exponent: 3, want: "1.000"},
+		{amount: -42, exponent: 0, want: "-42"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.want, formatAmount(tc.amount, tc.exponent))
+	}
+}
+
+func randomStatementLine(id int64, createdAt time.Time) repo.ListStatementLinesRow {
+	transferID := util.RandomInt(1, 1000)
+	return repo.ListStatementLinesRow{
+		ID:                    id,
+		Amount:                util.RandomInt(-1000, 1000),
+		CreatedAt:             createdAt,
+		TransferID:            &transferID,
+		Kind:                  repo.EntryKindTransfer,
+		CounterpartyAccountID: util.RandomInt(1, 1000),
+		CounterpartyOwner:     util.RandomOwner(),
+		Description:           util.RandomString(12),
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/max-rodziyevsky/go-simple-bank/token"
+	"net/http"
+	"time"
+)
+
+type renewAccessTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type renewAccessTokenResponse struct {
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+}
+
+func (s *Server) renewAccessToken(ctx *gin.Context) {
+	var req renewAccessTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	refreshPayload, err := s.tokenMaker.VerifyToken(req.RefreshToken, token.RefreshToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(codeUnauthorized, err))
+		return
+	}
+
+	session, err := s.store.GetSession(ctx, refreshPayload.ID)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	if session.IsBlocked {
+		err := errors.New("blocked session")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(codeUnauthorized, err))
+		return
+	}
+
+	if session.Username != refreshPayload.Username {
+		err := errors.New("incorrect session user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(codeUnauthorized, err))
+		return
+	}
+
+	if session.RefreshToken != req.RefreshToken {
+		err := errors.New("mismatched session token")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(codeUnauthorized, err))
+		return
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		err := errors.New("expired session")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(codeUnauthorized, err))
+		return
+	}
+
+	accessToken, accessPayload, err := s.tokenMaker.CreateToken(refreshPayload.Username, refreshPayload.Role, token.AccessToken, session.ID, s.config.AccessTokenDuration)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	response := renewAccessTokenResponse{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+	}
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	mockrepo "github.com/max-rodziyevsky/go-simple-bank/internal/repo/mock"
+	"github.com/max-rodziyevsky/go-simple-bank/token"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRenewAccessToken(t *testing.T) {
+	username := util.RandomOwner()
+
+	testCases := []struct {
+		name          string
+		buildToken    func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload)
+		buildStubs    func(mockStore *mockrepo.MockStore, refreshToken string, payload *token.Payload)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "OK",
+			buildToken: newRefreshToken(username, time.Hour),
+			buildStubs: func(mockStore *mockrepo.MockStore, refreshToken string, payload *token.Payload) {
+				mockStore.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(randomSession(payload, refreshToken), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response renewAccessTokenResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &response)
+				require.NoError(t, err)
+				require.NotEmpty(t, response.AccessToken)
+				require.NotZero(t, response.AccessTokenExpiresAt)
+			},
+		},
+		{
+			name:       "ExpiredToken",
+			buildToken: newRefreshToken(username, -time.Minute),
+			buildStubs: func(mockStore *mockrepo.MockStore, refreshToken string, payload *token.Payload) {
+				mockStore.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:       "AccessToken",
+			buildToken: newToken(username, token.AccessToken, time.Hour),
+			buildStubs: func(mockStore *mockrepo.MockStore, refreshToken string, payload *token.Payload) {
+				mockStore.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:       "SessionNotFound",
+			buildToken: newRefreshToken(username, time.Hour),
+			buildStubs: func(mockStore *mockrepo.MockStore, refreshToken string, payload *token.Payload) {
+				mockStore.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(repo.Session{}, repo.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:       "BlockedSession",
+			buildToken: newRefreshToken(username, time.Hour),
+			buildStubs: func(mockStore *mockrepo.MockStore, refreshToken string, payload *token.Payload) {
+				session := randomSession(payload, refreshToken)
+				session.IsBlocked = true
+
+				mockStore.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(session, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:       "IncorrectSessionUser",
+			buildToken: newRefreshToken(username, time.Hour),
+			buildStubs: func(mockStore *mockrepo.MockStore, refreshToken string, payload *token.Payload) {
+				session := randomSession(payload, refreshToken)
+				session.Username = util.RandomOwner()
+
+				mockStore.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(session, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:       "MismatchedSessionToken",
+			buildToken: newRefreshToken(username, time.Hour),
+			buildStubs: func(mockStore *mockrepo.MockStore, refreshToken string, payload *token.Payload) {
+				mockStore.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(randomSession(payload, "another_token"), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:       "InternalError",
+			buildToken: newRefreshToken(username, time.Hour),
+			buildStubs: func(mockStore *mockrepo.MockStore, refreshToken string, payload *token.Payload) {
+				mockStore.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(repo.Session{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mockrepo.NewMockStore(ctrl)
+			server := newTestServer(t, mockStore)
+
+			refreshToken, payload := tc.buildToken(t, server.tokenMaker)
+			tc.buildStubs(mockStore, refreshToken, payload)
+
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+			require.NoError(t, err)
+
+			url := "/tokens/renew_access"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func newRefreshToken(username string, duration time.Duration) func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+	return newToken(username, token.RefreshToken, duration)
+}
+
+func newToken(username string, tokenType token.TokenType, duration time.Duration) func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+	return func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+		tokenString, payload, err := tokenMaker.CreateToken(username, util.DepositorRole, tokenType, uuid.Nil, duration)
+		require.NoError(t, err)
+		return tokenString, payload
+	}
+}
+
+func randomSession(payload *token.Payload, refreshToken string) repo.Session {
+	return repo.Session{
+		ID:           payload.ID,
+		Username:     payload.Username,
+		RefreshToken: refreshToken,
+		ExpiresAt:    payload.ExpiredAt,
+	}
+}
@@ -1,66 +1,300 @@
 package api
 
 import (
-	"database/sql"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"github.com/max-rodziyevsky/go-simple-bank/token"
+	"math"
 	"net/http"
+	"time"
 )
 
+// idempotencyKeyHeader lets clients retry POST /transfers without the risk of a double transfer
+const idempotencyKeyHeader = "Idempotency-Key"
+
+const maxIdempotencyKeyLength = 255
+
+var (
+	errIdempotencyKeyTooLong = fmt.Errorf("%s must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength)
+	errIdempotencyKeyReused  = fmt.Errorf("%s has already been used with a different request", idempotencyKeyHeader)
+)
+
+// Transfer modes: a posted transfer moves the money right away,
+// an authorized one holds it until it's captured or voided
+const (
+	transferModePost      = "post"
+	transferModeAuthorize = "authorize"
+)
+
+// defaultHoldDuration is used when the config doesn't set how long authorized transfers hold the money
+const defaultHoldDuration = 7 * 24 * time.Hour
+
+// maxMetadataKeys bounds the metadata of a transfer and the metadata filters of a listing
+const maxMetadataKeys = 20
+
 type createTransferRequest struct {
-	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
-	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
-	Amount        int64  `json:"amount" binding:"required,gt=0"`
-	Currency      string `json:"currency" binding:"required,currency"`
+	FromAccountID     int64             `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID       int64             `json:"to_account_id" binding:"required,min=1"`
+	Amount            int64             `json:"amount" binding:"required,gt=0"`
+	Currency          string            `json:"currency" binding:"required,currency"`
+	Description       string            `json:"description" binding:"max=255"`
+	ExternalReference string            `json:"external_reference" binding:"max=255"`
+	Metadata          map[string]string `json:"metadata" binding:"max=20,dive,keys,min=1,max=64,endkeys,max=500"`
+	Mode              string            `json:"mode" binding:"omitempty,oneof=post authorize"`
 }
 
 func (s *Server) createTransfer(ctx *gin.Context) {
 	var req createTransferRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
 		return
 	}
+	if req.FromAccountID == req.ToAccountID {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, errSameAccount))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
-	if !s.validCurrency(ctx, req.FromAccountID, req.Currency) {
+	var idempotency *repo.IdempotencyParams
+	if key := ctx.GetHeader(idempotencyKeyHeader); len(key) > 0 {
+		if len(key) > maxIdempotencyKeyLength {
+			ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, errIdempotencyKeyTooLong))
+			return
+		}
+
+		idempotency = &repo.IdempotencyParams{
+			Username:    authPayload.Username,
+			Key:         key,
+			RequestHash: requestHash(req),
+		}
+		if s.replayIdempotent(ctx, idempotency) {
+			return
+		}
+	}
+
+	fromAccount, valid := s.validCurrency(ctx, req.FromAccountID, req.Currency)
+	if !valid {
 		return
 	}
 
-	if !s.validCurrency(ctx, req.ToAccountID, req.Currency) {
+	if fromAccount.Owner != authPayload.Username {
+		ctx.JSON(http.StatusForbidden, errorResponse(codeForbidden, errAccountNotOwned))
+		return
+	}
+
+	toAccount, err := s.store.GetAccount(ctx, req.ToAccountID)
+	if err != nil {
+		handleError(ctx, err)
 		return
 	}
 
 	arg := repo.TransferTxParams{
-		FromAccountID: req.FromAccountID,
-		ToAccountID:   req.ToAccountID,
-		Amount:        req.Amount,
+		FromAccountID:     req.FromAccountID,
+		ToAccountID:       req.ToAccountID,
+		Amount:            req.Amount,
+		Description:       req.Description,
+		ExternalReference: req.ExternalReference,
+		Metadata:          encodeMetadata(req.Metadata),
+		Idempotency:       idempotency,
 	}
 
-	result, err := s.store.TransferTx(ctx, arg)
+	// the amount is given in the source currency, a destination in another currency means an exchange
+	exchange := toAccount.Currency != req.Currency
+
+	var result any
+	if req.Mode == transferModeAuthorize {
+		result, err = s.store.AuthorizeTransferTx(ctx, repo.AuthorizeTransferTxParams{
+			TransferTxParams: arg,
+			Exchange:         exchange,
+			ExpiresAt:        time.Now().Add(s.holdDuration()),
+		})
+	} else {
+		transferTx := s.store.TransferTx
+		if exchange {
+			transferTx = s.store.ExchangeTransferTx
+		}
+		result, err = transferTx(ctx, arg)
+	}
+	if errors.Is(err, repo.ErrIdempotencyKeyExists) && s.replayIdempotent(ctx, idempotency) {
+		return
+	}
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		handleError(ctx, err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, result)
 }
 
-func (s *Server) validCurrency(ctx *gin.Context, id int64, currency string) bool {
+// transfer directions relative to the account
+const (
+	directionIn   = "in"
+	directionOut  = "out"
+	directionBoth = "both"
+)
+
+var (
+	errInvalidAmountRange  = errors.New("max_amount must not be less than min_amount")
+	errTooManyMetadataKeys = fmt.Errorf("metadata must have at most %d keys", maxMetadataKeys)
+)
+
+// metadataQueryKey is the prefix of metadata filters, metadata[order]=42 matches transfers with that pair
+const metadataQueryKey = "metadata"
+
+type listAccountTransfersRequest struct {
+	Direction         string    `form:"direction" binding:"omitempty,oneof=in out both"`
+	From              time.Time `form:"from"`
+	To                time.Time `form:"to"`
+	MinAmount         int64     `form:"min_amount" binding:"min=0"`
+	MaxAmount         int64     `form:"max_amount" binding:"min=0"`
+	Description       string    `form:"description" binding:"max=255"`
+	ExternalReference string    `form:"external_reference" binding:"max=255"`
+	Cursor            string    `form:"cursor"`
+	PageSize          int32     `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// listAccountTransfers returns transfers of the account ordered by creation time, page by page.
+// Transfers can be searched by a part of the description, the exact external reference and metadata pairs.
+// The amount range is in the currency of the account, so incoming transfers are filtered by the credited amount.
+func (s *Server) listAccountTransfers(ctx *gin.Context) {
+	var uri accountURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	var req listAccountTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	to, err := periodEnd(req.From, req.To)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	maxAmount := req.MaxAmount
+	if maxAmount == 0 {
+		maxAmount = math.MaxInt64
+	}
+	if maxAmount < req.MinAmount {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, errInvalidAmountRange))
+		return
+	}
+
+	metadata := ctx.QueryMap(metadataQueryKey)
+	if len(metadata) > maxMetadataKeys {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, errTooManyMetadataKeys))
+		return
+	}
+
+	cursor, err := decodeCursor(req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	if _, ok := s.ownedAccount(ctx, uri.ID); !ok {
+		return
+	}
+
+	pageSize := pageSizeOrDefault(req.PageSize)
+	transfers, err := s.store.ListTransfers(ctx, repo.ListTransfersParams{
+		Outgoing:          req.Direction != directionIn,
+		AccountID:         uri.ID,
+		Incoming:          req.Direction != directionOut,
+		FromTime:          req.From,
+		ToTime:            to,
+		MinAmount:         req.MinAmount,
+		MaxAmount:         maxAmount,
+		Description:       req.Description,
+		ExternalReference: req.ExternalReference,
+		Metadata:          encodeMetadata(metadata),
+		AfterCreatedAt:    cursor.CreatedAt,
+		AfterID:           cursor.ID,
+		PageSize:          pageSize + 1,
+	})
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newPage(transfers, pageSize, func(transfer repo.Transfer) pageCursor {
+		return pageCursor{CreatedAt: transfer.CreatedAt, ID: transfer.ID}
+	}))
+}
+
+// encodeMetadata returns the metadata as a JSON object. It's empty without metadata,
+// which as a filter is contained in the metadata of any transfer.
+func encodeMetadata(metadata map[string]string) json.RawMessage {
+	if len(metadata) == 0 {
+		return json.RawMessage(`{}`)
+	}
+
+	// a map of strings always encodes
+	data, _ := json.Marshal(metadata)
+	return data
+}
+
+func (s *Server) validCurrency(ctx *gin.Context, id int64, currency string) (repo.Account, bool) {
 	account, err := s.store.GetAccount(ctx, id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return false
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return false
+		handleError(ctx, err)
+		return account, false
 	}
 
 	if account.Currency != currency {
-		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		err := fmt.Errorf("%w: account [%d] has %s, not %s", repo.ErrCurrencyMismatch, account.ID, account.Currency, currency)
+		handleError(ctx, err)
+		return account, false
+	}
+
+	return account, true
+}
+
+// replayIdempotent writes the stored response if the request has already been executed under the same key.
+// It reports whether the response has been written.
+func (s *Server) replayIdempotent(ctx *gin.Context, arg *repo.IdempotencyParams) bool {
+	stored, err := s.store.GetIdempotencyKey(ctx, repo.GetIdempotencyKeyParams{
+		Username: arg.Username,
+		Key:      arg.Key,
+	})
+	if errors.Is(err, repo.ErrRecordNotFound) {
 		return false
 	}
+	if err != nil {
+		handleError(ctx, err)
+		return true
+	}
+
+	if stored.RequestHash != arg.RequestHash {
+		ctx.JSON(http.StatusConflict, errorResponse(codeIdempotencyKeyReused, errIdempotencyKeyReused))
+		return true
+	}
 
+	ctx.Data(http.StatusOK, gin.MIMEJSON, stored.Response)
 	return true
 }
+
+// requestHash fingerprints the bound request, so formatting of the body doesn't matter
+func requestHash(req any) string {
+	data, _ := json.Marshal(req)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// holdDuration is how long an authorized transfer holds the money before it's voided
+func (s *Server) holdDuration() time.Duration {
+	if s.config.HoldDuration > 0 {
+		return s.config.HoldDuration
+	}
+	return defaultHoldDuration
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"net/http"
+)
+
+type userURI struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+type userTransferLimitURI struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+	Currency string `uri:"currency" binding:"required,currency"`
+}
+
+type transferLimitURI struct {
+	Currency string `uri:"currency" binding:"required,currency"`
+}
+
+// transferLimitRequest replaces all the limits at once, a zero limit means no limit
+type transferLimitRequest struct {
+	MaxAmount           int64 `json:"max_amount" binding:"min=0"`
+	AccountDailyLimit   int64 `json:"account_daily_limit" binding:"min=0"`
+	AccountMonthlyLimit int64 `json:"account_monthly_limit" binding:"min=0"`
+	UserDailyLimit      int64 `json:"user_daily_limit" binding:"min=0"`
+	UserMonthlyLimit    int64 `json:"user_monthly_limit" binding:"min=0"`
+}
+
+// listUserTransferLimits returns the limits applied to the user in every currency,
+// overridden tells whether they were set for the user or are the defaults of the currency
+func (s *Server) listUserTransferLimits(ctx *gin.Context) {
+	var uri userURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	if _, err := s.store.GetUser(ctx, uri.Username); err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	limits, err := s.store.ListEffectiveTransferLimits(ctx, uri.Username)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, limits)
+}
+
+// setUserTransferLimit overrides the defaults of the currency for the user
+func (s *Server) setUserTransferLimit(ctx *gin.Context) {
+	var uri userTransferLimitURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	var req transferLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	limit, err := s.store.UpsertUserTransferLimit(ctx, repo.UpsertUserTransferLimitParams{
+		Username:            uri.Username,
+		Currency:            uri.Currency,
+		MaxAmount:           req.MaxAmount,
+		AccountDailyLimit:   req.AccountDailyLimit,
+		AccountMonthlyLimit: req.AccountMonthlyLimit,
+		UserDailyLimit:      req.UserDailyLimit,
+		UserMonthlyLimit:    req.UserMonthlyLimit,
+	})
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, limit)
+}
+
+// deleteUserTransferLimit removes the override, the user gets the defaults of the currency again
+func (s *Server) deleteUserTransferLimit(ctx *gin.Context) {
+	var uri userTransferLimitURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	err := s.store.DeleteUserTransferLimit(ctx, repo.DeleteUserTransferLimitParams{
+		Username: uri.Username,
+		Currency: uri.Currency,
+	})
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// setTransferLimit sets the default limits of the currency, which apply to users without an override
+func (s *Server) setTransferLimit(ctx *gin.Context) {
+	var uri transferLimitURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	var req transferLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	limit, err := s.store.UpsertTransferLimit(ctx, repo.UpsertTransferLimitParams{
+		Currency:            uri.Currency,
+		MaxAmount:           req.MaxAmount,
+		AccountDailyLimit:   req.AccountDailyLimit,
+		AccountMonthlyLimit: req.AccountMonthlyLimit,
+		UserDailyLimit:      req.UserDailyLimit,
+		UserMonthlyLimit:    req.UserMonthlyLimit,
+	})
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, limit)
+}
@@ -0,0 +1,273 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	mockrepo "github.com/max-rodziyevsky/go-simple-bank/internal/repo/mock"
+	"github.com/max-rodziyevsky/go-simple-bank/token"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestListUserTransferLimits(t *testing.T) {
+	user, _ := createRandomUser(t)
+	admin := util.RandomOwner()
+
+	limits := []repo.ListEffectiveTransferLimitsRow{
+		{Currency: util.EUR, MaxAmount: 1000, UserDailyLimit: 5000},
+		{Currency: util.USD, MaxAmount: 2000, UserDailyLimit: 8000, Overridden: true},
+	}
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(mockStore *mockrepo.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				mockStore.EXPECT().ListEffectiveTransferLimits(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(limits, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []repo.ListEffectiveTransferLimitsRow
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, limits, got)
+			},
+		},
+		{
+			name: "UserNotFound",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(repo.User{}, repo.ErrRecordNotFound)
+				mockStore.EXPECT().ListEffectiveTransferLimits(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "NotAdmin",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().ListEffectiveTransferLimits(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mockrepo.NewMockStore(ctrl)
+			tc.buildStubs(mockStore)
+			stubTokenNotRevoked(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/users/%s/transfer_limits", user.Username)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestSetUserTransferLimit(t *testing.T) {
+	username := util.RandomOwner()
+	admin := util.RandomOwner()
+
+	limit := repo.UserTransferLimit{
+		Username:          username,
+		Currency:          util.USD,
+		MaxAmount:         1000,
+		AccountDailyLimit: 3000,
+		UserMonthlyLimit:  50000,
+		UpdatedAt:         time.Now().UTC().Truncate(time.Second),
+	}
+
+	testCases := []struct {
+		name          string
+		currency      string
+		body          gin.H
+		buildStubs    func(mockStore *mockrepo.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			currency: util.USD,
+			body: gin.H{
+				"max_amount":          1000,
+				"account_daily_limit": 3000,
+				"user_monthly_limit":  50000,
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				arg := repo.UpsertUserTransferLimitParams{
+					Username:          username,
+					Currency:          util.USD,
+					MaxAmount:         1000,
+					AccountDailyLimit: 3000,
+					UserMonthlyLimit:  50000,
+				}
+				mockStore.EXPECT().UpsertUserTransferLimit(gomock.Any(), gomock.Eq(arg)).Times(1).Return(limit, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got repo.UserTransferLimit
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, limit, got)
+			},
+		},
+		{
+			name:     "NegativeLimit",
+			currency: util.USD,
+			body:     gin.H{"max_amount": -1},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().UpsertUserTransferLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "UnsupportedCurrency",
+			currency: "XYZ",
+			body:     gin.H{"max_amount": 1000},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().UpsertUserTransferLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "UserNotFound",
+			currency: util.USD,
+			body:     gin.H{"max_amount": 1000},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().UpsertUserTransferLimit(gomock.Any(), gomock.Any()).Times(1).Return(repo.UserTransferLimit{}, repo.ErrForeignKeyViolation)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mockrepo.NewMockStore(ctrl)
+			tc.buildStubs(mockStore)
+			stubTokenNotRevoked(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/users/%s/transfer_limits/%s", username, tc.currency)
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, admin, util.AdminRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestDeleteUserTransferLimit(t *testing.T) {
+	username := util.RandomOwner()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mockrepo.NewMockStore(ctrl)
+	arg := repo.DeleteUserTransferLimitParams{Username: username, Currency: util.EUR}
+	mockStore.EXPECT().DeleteUserTransferLimit(gomock.Any(), gomock.Eq(arg)).Times(1).Return(nil)
+	stubTokenNotRevoked(mockStore)
+
+	server := newTestServer(t, mockStore)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/users/%s/transfer_limits/%s", username, util.EUR)
+	request, err := http.NewRequest(http.MethodDelete, url, nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, util.RandomOwner(), util.AdminRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestSetTransferLimit(t *testing.T) {
+	limit := repo.TransferLimit{
+		Currency:       util.EUR,
+		MaxAmount:      500000,
+		UserDailyLimit: 1000000,
+		UpdatedAt:      time.Now().UTC().Truncate(time.Second),
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mockrepo.NewMockStore(ctrl)
+	arg := repo.UpsertTransferLimitParams{
+		Currency:       util.EUR,
+		MaxAmount:      500000,
+		UserDailyLimit: 1000000,
+	}
+	mockStore.EXPECT().UpsertTransferLimit(gomock.Any(), gomock.Eq(arg)).Times(1).Return(limit, nil)
+	stubTokenNotRevoked(mockStore)
+
+	server := newTestServer(t, mockStore)
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{"max_amount": 500000, "user_daily_limit": 1000000})
+	require.NoError(t, err)
+
+	url := fmt.Sprintf("/transfer_limits/%s", util.EUR)
+	request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, util.RandomOwner(), util.AdminRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var got repo.TransferLimit
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	require.Equal(t, limit, got)
+}
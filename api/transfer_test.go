@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -9,17 +10,26 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
 	mockrepo "github.com/max-rodziyevsky/go-simple-bank/internal/repo/mock"
+	"github.com/max-rodziyevsky/go-simple-bank/token"
 	"github.com/max-rodziyevsky/go-simple-bank/util"
 	"github.com/stretchr/testify/require"
+	"io"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 )
 
 func TestCreateTransfer(t *testing.T) {
-	account1 := randomAccount()
-	account2 := randomAccount()
-	account3 := randomAccount()
+	user1, _ := createRandomUser(t)
+	user2, _ := createRandomUser(t)
+	user3, _ := createRandomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account3 := randomAccount(user3.Username)
 
 	account1.Currency = util.USD
 	account2.Currency = util.USD
@@ -30,6 +40,7 @@ func TestCreateTransfer(t *testing.T) {
 	testCases := []struct {
 		name          string
 		req           gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(mockStore *mockrepo.MockStore)
 		checkResponse func(recorder *httptest.ResponseRecorder)
 	}{
@@ -41,6 +52,9 @@ func TestCreateTransfer(t *testing.T) {
 				"amount":          amount,
 				"currency":        util.USD,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
 				mockStore.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
@@ -49,6 +63,42 @@ func TestCreateTransfer(t *testing.T) {
 					FromAccountID: account1.ID,
 					ToAccountID:   account2.ID,
 					Amount:        amount,
+					Metadata:      json.RawMessage(`{}`),
+				}
+
+				mockStore.EXPECT().
+					TransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "WithMetadata",
+			req: gin.H{
+				"from_account_id":    account1.ID,
+				"to_account_id":      account2.ID,
+				"amount":             amount,
+				"currency":           util.USD,
+				"description":        "rent for March",
+				"external_reference": "INV-42",
+				"metadata":           gin.H{"order": "42", "channel": "web"},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				mockStore.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+
+				arg := repo.TransferTxParams{
+					FromAccountID:     account1.ID,
+					ToAccountID:       account2.ID,
+					Amount:            amount,
+					Description:       "rent for March",
+					ExternalReference: "INV-42",
+					Metadata:          json.RawMessage(`{"channel":"web","order":"42"}`),
 				}
 
 				mockStore.EXPECT().
@@ -59,6 +109,82 @@ func TestCreateTransfer(t *testing.T) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 			},
 		},
+		{
+			name: "TooLongDescription",
+			req: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+				"description":     util.RandomString(256),
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "EmptyMetadataKey",
+			req: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+				"metadata":        gin.H{"": "42"},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			req: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(0)
+				mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
 		{
 			name: "InvalidAmount",
 			req: gin.H{
@@ -67,6 +193,9 @@ func TestCreateTransfer(t *testing.T) {
 				"amount":          0,
 				"currency":        util.USD,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(0).Return(account1, nil)
 				mockStore.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(0).Return(account2, nil)
@@ -79,6 +208,28 @@ func TestCreateTransfer(t *testing.T) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
+		{
+			name: "SameAccount",
+			req: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account1.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+
+				mockStore.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name: "InvalidFromAccountID",
 			req: gin.H{
@@ -87,6 +238,9 @@ func TestCreateTransfer(t *testing.T) {
 				"amount":          amount,
 				"currency":        util.USD,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(0).Return(account1, nil)
 				mockStore.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(0).Return(account2, nil)
@@ -107,8 +261,11 @@ func TestCreateTransfer(t *testing.T) {
 				"amount":          amount,
 				"currency":        util.USD,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
-				mockStore.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(repo.Account{}, sql.ErrNoRows)
+				mockStore.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(repo.Account{}, repo.ErrRecordNotFound)
 				mockStore.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(0)
 				mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
 			},
@@ -124,9 +281,12 @@ func TestCreateTransfer(t *testing.T) {
 				"amount":          amount,
 				"currency":        util.USD,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
-				mockStore.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(repo.Account{}, sql.ErrNoRows)
+				mockStore.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(repo.Account{}, repo.ErrRecordNotFound)
 				mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -141,6 +301,9 @@ func TestCreateTransfer(t *testing.T) {
 				"amount":          amount,
 				"currency":        util.USD,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user3.Username, util.DepositorRole, time.Minute)
+			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
 				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
@@ -152,22 +315,112 @@ func TestCreateTransfer(t *testing.T) {
 			},
 		},
 		{
-			name: "ToAccountCurrencyMismatch",
+			name: "Exchange",
 			req: gin.H{
 				"from_account_id": account1.ID,
 				"to_account_id":   account3.ID,
 				"amount":          amount,
 				"currency":        util.USD,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
 				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+
+				arg := repo.TransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account3.ID,
+					Amount:        amount,
+					Metadata:      json.RawMessage(`{}`),
+				}
+
+				mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+				mockStore.EXPECT().ExchangeTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Authorize",
+			req: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account3.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+				"mode":            transferModeAuthorize,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+
 				mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+				mockStore.EXPECT().ExchangeTransferTx(gomock.Any(), gomock.Any()).Times(0)
+				mockStore.EXPECT().
+					AuthorizeTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.AuthorizeTransferTxParams) (repo.PendingTransferTxResult, error) {
+						require.Equal(t, repo.TransferTxParams{
+							FromAccountID: account1.ID,
+							ToAccountID:   account3.ID,
+							Amount:        amount,
+							Metadata:      json.RawMessage(`{}`),
+						}, arg.TransferTxParams)
+						require.True(t, arg.Exchange)
+						require.WithinDuration(t, time.Now().Add(defaultHoldDuration), arg.ExpiresAt, time.Minute)
+
+						return repo.PendingTransferTxResult{}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidMode",
+			req: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+				"mode":            "capture",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				mockStore.EXPECT().AuthorizeTransferTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
+		{
+			name: "RateNotFound",
+			req: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account3.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				mockStore.EXPECT().ExchangeTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(repo.TransferTxResult{}, repo.ErrRateNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
 		{
 			name: "InvalidCurrency",
 			req: gin.H{
@@ -176,6 +429,9 @@ func TestCreateTransfer(t *testing.T) {
 				"amount":          amount,
 				"currency":        "XYZ",
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(0)
 				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(0)
@@ -193,6 +449,9 @@ func TestCreateTransfer(t *testing.T) {
 				"amount":          -amount,
 				"currency":        "XYZ",
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(0)
 				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(0)
@@ -210,6 +469,9 @@ func TestCreateTransfer(t *testing.T) {
 				"amount":          amount,
 				"currency":        util.USD,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(repo.Account{}, sql.ErrConnDone)
 				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(0)
@@ -219,6 +481,49 @@ func TestCreateTransfer(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+		{
+			name: "InsufficientFunds",
+			req: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(repo.TransferTxResult{}, repo.ErrInsufficientFunds)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "LimitExceeded",
+			req: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				err := &repo.LimitExceededError{Limit: repo.LimitUserDaily, Max: amount, Used: 1}
+
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(repo.TransferTxResult{}, err)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeLimitExceeded)
+			},
+		},
 		{
 			name: "TransferTxError",
 			req: gin.H{
@@ -227,6 +532,9 @@ func TestCreateTransfer(t *testing.T) {
 				"amount":          amount,
 				"currency":        util.USD,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
 			buildStubs: func(mockStore *mockrepo.MockStore) {
 				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
 				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
@@ -247,8 +555,9 @@ func TestCreateTransfer(t *testing.T) {
 
 			mockStore := mockrepo.NewMockStore(ctrl)
 			tc.buildStubs(mockStore)
+			stubTokenNotRevoked(mockStore)
 
-			server := NewServer(mockStore)
+			server := newTestServer(t, mockStore)
 			recorder := httptest.NewRecorder()
 
 			data, err := json.Marshal(tc.req)
@@ -258,9 +567,486 @@ func TestCreateTransfer(t *testing.T) {
 			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
 			require.NoError(t, err)
 
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+
+	}
+}
+
+func TestCreateTransferIdempotency(t *testing.T) {
+	user1, _ := createRandomUser(t)
+	user2, _ := createRandomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	amount := util.RandomMoney()
+	key := util.RandomString(32)
+
+	req := createTransferRequest{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+		Currency:      util.USD,
+	}
+	idempotency := &repo.IdempotencyParams{
+		Username:    user1.Username,
+		Key:         key,
+		RequestHash: requestHash(req),
+	}
+	keyArg := repo.GetIdempotencyKeyParams{
+		Username: user1.Username,
+		Key:      key,
+	}
+
+	result := repo.TransferTxResult{
+		Transfer: repo.Transfer{
+			ID:            util.RandomInt(1, 1000),
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+		},
+	}
+	response, err := json.Marshal(result)
+	require.NoError(t, err)
+
+	stored := repo.IdempotencyKey{
+		Username:    user1.Username,
+		Key:         key,
+		RequestHash: idempotency.RequestHash,
+		Response:    response,
+	}
+
+	testCases := []struct {
+		name          string
+		key           string
+		amount        int64
+		buildStubs    func(mockStore *mockrepo.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "FirstRequest",
+			key:    key,
+			amount: amount,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(keyArg)).Times(1).Return(repo.IdempotencyKey{}, repo.ErrRecordNotFound)
+				mockStore.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				mockStore.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+
+				arg := repo.TransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        amount,
+					Metadata:      json.RawMessage(`{}`),
+					Idempotency:   idempotency,
+				}
+				mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, string(response), recorder.Body.String())
+			},
+		},
+		{
+			name:   "Replay",
+			key:    key,
+			amount: amount,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(keyArg)).Times(1).Return(stored, nil)
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, string(response), recorder.Body.String())
+			},
+		},
+		{
+			name:   "ReusedWithDifferentBody",
+			key:    key,
+			amount: amount + 1,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(keyArg)).Times(1).Return(stored, nil)
+				mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:   "ConcurrentRetry",
+			key:    key,
+			amount: amount,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				gomock.InOrder(
+					mockStore.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(keyArg)).Times(1).Return(repo.IdempotencyKey{}, repo.ErrRecordNotFound),
+					mockStore.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(keyArg)).Times(1).Return(stored, nil),
+				)
+				mockStore.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				mockStore.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+				mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(repo.TransferTxResult{}, repo.ErrIdempotencyKeyExists)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, string(response), recorder.Body.String())
+			},
+		},
+		{
+			name:   "KeySweptBeforeReplay",
+			key:    key,
+			amount: amount,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(keyArg)).Times(2).Return(repo.IdempotencyKey{}, repo.ErrRecordNotFound)
+				mockStore.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				mockStore.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+				mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(repo.TransferTxResult{}, repo.ErrIdempotencyKeyExists)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:   "KeyTooLong",
+			key:    util.RandomString(maxIdempotencyKeyLength + 1),
+			amount: amount,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+				mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "GetIdempotencyKeyError",
+			key:    key,
+			amount: amount,
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(repo.IdempotencyKey{}, sql.ErrConnDone)
+				mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mockrepo.NewMockStore(ctrl)
+			tc.buildStubs(mockStore)
+			stubTokenNotRevoked(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          tc.amount,
+				"currency":        util.USD,
+			})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			request.Header.Set(idempotencyKeyHeader, tc.key)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(recorder)
 		})
+	}
+}
+
+func TestListAccountTransfers(t *testing.T) {
+	user, _ := createRandomUser(t)
+	otherUser, _ := createRandomUser(t)
+	account := randomAccount(user.Username)
 
+	n := 5
+	transfers := make([]repo.Transfer, n)
+	for i := range transfers {
+		transfers[i] = randomTransfer(account.ID, util.RandomInt(1, 1000))
 	}
+
+	last := transfers[n-2]
+	cursor := pageCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	from := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	to := time.Now().UTC().Truncate(time.Second)
+
+	defaultArg := repo.ListTransfersParams{
+		Outgoing:  true,
+		AccountID: account.ID,
+		Incoming:  true,
+		ToTime:    maxTime,
+		MaxAmount: math.MaxInt64,
+		Metadata:  json.RawMessage(`{}`),
+		PageSize:  defaultPageSize + 1,
+	}
+
+	testCases := []struct {
+		name          string
+		accountID     int64
+		query         url.Values
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(mockStore *mockrepo.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			accountID: account.ID,
+			query:     url.Values{},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				mockStore.EXPECT().ListTransfers(gomock.Any(), gomock.Eq(defaultArg)).Times(1).Return(transfers, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				got := requireBodyMatchTransferPage(t, recorder.Body)
+				require.Equal(t, transfers, got.Items)
+				require.Empty(t, got.NextCursor)
+			},
+		},
+		{
+			name:      "NextPage",
+			accountID: account.ID,
+			query: url.Values{
+				"direction":  {directionOut},
+				"from":       {from.Format(time.RFC3339)},
+				"to":         {to.Format(time.RFC3339)},
+				"min_amount": {"10"},
+				"max_amount": {"500"},
+				"page_size":  {fmt.Sprint(n - 1)},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				arg := repo.ListTransfersParams{
+					Outgoing:  true,
+					AccountID: account.ID,
+					FromTime:  from,
+					ToTime:    to,
+					MinAmount: 10,
+					MaxAmount: 500,
+					Metadata:  json.RawMessage(`{}`),
+					PageSize:  int32(n),
+				}
+
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				mockStore.EXPECT().ListTransfers(gomock.Any(), gomock.Eq(arg)).Times(1).Return(transfers, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				got := requireBodyMatchTransferPage(t, recorder.Body)
+				require.Equal(t, transfers[:n-1], got.Items)
+				require.Equal(t, cursor.encode(), got.NextCursor)
+			},
+		},
+		{
+			name:      "WithCursor",
+			accountID: account.ID,
+			query:     url.Values{"cursor": {cursor.encode()}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				arg := defaultArg
+				arg.AfterCreatedAt = cursor.CreatedAt
+				arg.AfterID = cursor.ID
+
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				mockStore.EXPECT().ListTransfers(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]repo.Transfer{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "Search",
+			accountID: account.ID,
+			query: url.Values{
+				"description":        {"rent"},
+				"external_reference": {"INV-42"},
+				"metadata[order]":    {"42"},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				arg := defaultArg
+				arg.Description = "rent"
+				arg.ExternalReference = "INV-42"
+				arg.Metadata = json.RawMessage(`{"order":"42"}`)
+
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				mockStore.EXPECT().ListTransfers(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]repo.Transfer{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "NotOwner",
+			accountID: account.ID,
+			query:     url.Values{},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, otherUser.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				mockStore.EXPECT().ListTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			accountID: account.ID,
+			query:     url.Values{},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().ListTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidDirection",
+			accountID: account.ID,
+			query:     url.Values{"direction": {"sideways"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().ListTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidPeriod",
+			accountID: account.ID,
+			query:     url.Values{"from": {to.Format(time.RFC3339)}, "to": {from.Format(time.RFC3339)}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().ListTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidAmountRange",
+			accountID: account.ID,
+			query:     url.Values{"min_amount": {"100"}, "max_amount": {"10"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().ListTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidCursor",
+			accountID: account.ID,
+			query:     url.Values{"cursor": {"not a cursor"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().ListTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InternalError",
+			accountID: account.ID,
+			query:     url.Values{},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mockrepo.MockStore) {
+				mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				mockStore.EXPECT().ListTransfers(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mockrepo.NewMockStore(ctrl)
+			tc.buildStubs(mockStore)
+			stubTokenNotRevoked(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/transfers?%s", tc.accountID, tc.query.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func randomTransfer(fromAccountID, toAccountID int64) repo.Transfer {
+	amount := util.RandomMoney()
+	return repo.Transfer{
+		ID:            util.RandomInt(1, 1000),
+		FromAccountID: fromAccountID,
+		ToAccountID:   toAccountID,
+		Amount:        amount,
+		ToAmount:      amount,
+		Rate:          "1",
+		CreatedAt:     time.Now().UTC().Truncate(time.Second),
+		Metadata:      json.RawMessage(`{}`),
+		Status:        repo.TransferStatusPosted,
+	}
+}
+
+func requireBodyMatchTransferPage(t *testing.T, body *bytes.Buffer) page[repo.Transfer] {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var got page[repo.Transfer]
+	require.NoError(t, json.Unmarshal(data, &got))
+	return got
 }
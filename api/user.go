@@ -1,14 +1,20 @@
 package api
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
-	"github.com/lib/pq"
+	"github.com/google/uuid"
 	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"github.com/max-rodziyevsky/go-simple-bank/token"
 	"github.com/max-rodziyevsky/go-simple-bank/util"
 	"net/http"
 	"time"
 )
 
+// errInvalidCredentials is returned for an unknown username and a wrong password alike,
+// so the login doesn't tell which usernames exist
+var errInvalidCredentials = errors.New("invalid username or password")
+
 type createUserRequest struct {
 	Username string `json:"username" binding:"required,alphanum,min=2"`
 	FullName string `json:"full_name" binding:"required"`
@@ -16,7 +22,7 @@ type createUserRequest struct {
 	Password string `json:"password" binding:"required,min=4"`
 }
 
-type createUserResponse struct {
+type userResponse struct {
 	Username         string    `json:"username"`
 	FullName         string    `json:"full_name"`
 	Email            string    `json:"email"`
@@ -24,17 +30,28 @@ type createUserResponse struct {
 	CreatedAt        time.Time `json:"created_at"`
 }
 
+// newUserResponse strips the hashed password from the user before it's sent to a client
+func newUserResponse(user repo.User) userResponse {
+	return userResponse{
+		Username:         user.Username,
+		FullName:         user.FullName,
+		Email:            user.Email,
+		ChangePasswordAt: user.ChangePasswordAt,
+		CreatedAt:        user.CreatedAt,
+	}
+}
+
 func (s *Server) createUser(ctx *gin.Context) {
 	var req createUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
 		return
 	}
 
 	//hash password
 	hashedPassword, err := util.HashPassword(req.Password)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		handleError(ctx, err)
 		return
 	}
 
@@ -47,23 +64,140 @@ func (s *Server) createUser(ctx *gin.Context) {
 
 	user, err := s.store.CreateUser(ctx, arg)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code.Name() {
-			case repo.UniqueViolation:
-				ctx.JSON(http.StatusForbidden, errorResponse(err))
-				return
-			}
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		handleError(ctx, err)
 		return
 	}
 
-	response := createUserResponse{
-		Username:         user.Username,
-		FullName:         user.FullName,
-		Email:            user.Email,
-		ChangePasswordAt: user.ChangePasswordAt,
-		CreatedAt:        user.CreatedAt,
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+type loginUserRequest struct {
+	Username string `json:"username" binding:"required,alphanum"`
+	Password string `json:"password" binding:"required,min=4"`
+}
+
+type loginUserResponse struct {
+	SessionID             uuid.UUID    `json:"session_id"`
+	AccessToken           string       `json:"access_token"`
+	AccessTokenExpiresAt  time.Time    `json:"access_token_expires_at"`
+	RefreshToken          string       `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time    `json:"refresh_token_expires_at"`
+	User                  userResponse `json:"user"`
+}
+
+func (s *Server) loginUser(ctx *gin.Context) {
+	var req loginUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	user, err := s.store.GetUser(ctx, req.Username)
+	if errors.Is(err, repo.ErrRecordNotFound) {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(codeUnauthorized, errInvalidCredentials))
+		return
+	}
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	err = util.CheckHashedPassword(user.HashPassword, req.Password)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(codeUnauthorized, errInvalidCredentials))
+		return
+	}
+
+	refreshToken, refreshPayload, err := s.tokenMaker.CreateToken(user.Username, user.Role, token.RefreshToken, uuid.Nil, s.config.RefreshTokenDuration)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	// the access token is bound to the session, so blocking the session rejects it too
+	accessToken, accessPayload, err := s.tokenMaker.CreateToken(user.Username, user.Role, token.AccessToken, refreshPayload.ID, s.config.AccessTokenDuration)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	// the session is identified by the refresh token id, so it can be looked up on renewal
+	session, err := s.store.CreateSession(ctx, repo.CreateSessionParams{
+		ID:           refreshPayload.ID,
+		Username:     user.Username,
+		RefreshToken: refreshToken,
+		UserAgent:    ctx.Request.UserAgent(),
+		ClientIp:     ctx.ClientIP(),
+		IsBlocked:    false,
+		ExpiresAt:    refreshPayload.ExpiredAt,
+	})
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	response := loginUserResponse{
+		SessionID:             session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
+		User:                  newUserResponse(user),
 	}
 	ctx.JSON(http.StatusOK, response)
 }
+
+type logoutUserRequest struct {
+	SessionID uuid.UUID `json:"session_id" binding:"required"`
+}
+
+func (s *Server) logoutUser(ctx *gin.Context) {
+	var req logoutUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(codeInvalidRequest, err))
+		return
+	}
+
+	session, err := s.store.GetSession(ctx, req.SessionID)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if session.Username != authPayload.Username {
+		err := errors.New("session doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusForbidden, errorResponse(codeForbidden, err))
+		return
+	}
+
+	_, err = s.store.BlockSession(ctx, session.ID)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	// the refresh token and the access token used for logout are revoked as well,
+	// access tokens issued for the session before are rejected as its session is blocked
+	err = s.store.RevokeToken(ctx, repo.RevokeTokenParams{
+		ID:        session.ID,
+		Username:  session.Username,
+		ExpiresAt: session.ExpiresAt,
+	})
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	err = s.store.RevokeToken(ctx, repo.RevokeTokenParams{
+		ID:        authPayload.ID,
+		Username:  authPayload.Username,
+		ExpiresAt: authPayload.ExpiredAt,
+	})
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
@@ -2,13 +2,29 @@ package api
 
 import (
 	"github.com/go-playground/validator/v10"
-	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/currency"
+	"regexp"
+	"strings"
 )
 
-var validCurrency validator.Func = func(fl validator.FieldLevel) bool {
-	if currency, ok := fl.Field().Interface().(string); ok {
-		// check currency is supported
-		return util.IsSupportedCurrency(currency)
+// rateFormat fits the numeric(20, 10) column of rates
+var rateFormat = regexp.MustCompile(`^\d{1,10}(\.\d{1,10})?$`)
+
+// currencyValidator accepts currencies which are enabled in the registry
+func currencyValidator(currencies *currency.Registry) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		if code, ok := fl.Field().Interface().(string); ok {
+			// check currency is supported
+			return currencies.IsSupported(code)
+		}
+		return false
+	}
+}
+
+var validRate validator.Func = func(fl validator.FieldLevel) bool {
+	if rate, ok := fl.Field().Interface().(string); ok {
+		// a rate is a positive decimal, so it must contain a non-zero digit
+		return rateFormat.MatchString(rate) && strings.ContainsAny(rate, "123456789")
 	}
 	return false
 }
@@ -1,19 +1,150 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
 	_ "github.com/lib/pq"
 	"github.com/max-rodziyevsky/go-simple-bank/api"
 	"github.com/max-rodziyevsky/go-simple-bank/configs"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/currency"
 	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/scheduler"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 )
 
+const verifyLedgerCommand = "verify-ledger"
+
+// defaultShutdownTimeout is used when the config doesn't set how long requests in flight are drained
+const defaultShutdownTimeout = 30 * time.Second
+
+const dbPingTimeout = 5 * time.Second
+
 func main() {
-	run()
+	if len(os.Args) < 2 {
+		run()
+		return
+	}
+
+	switch os.Args[1] {
+	case verifyLedgerCommand:
+		os.Exit(verifyLedger())
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q, usage: go-simple-bank [%s]\n", os.Args[1], verifyLedgerCommand)
+		os.Exit(2)
+	}
 }
 
 func run() {
+	// the first signal starts the shutdown, a second one kills the process right away
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	config, conn := setup()
+	db := repo.NewStore(conn)
+	prometheus.MustRegister(collectors.NewDBStatsCollector(conn, "simple_bank"))
+	currencies, err := currency.NewRegistry(ctx, db, config.CurrencyCacheTTL)
+	if err != nil {
+		log.Fatal("can't create the currency registry: ", err)
+	}
+
+	server, err := api.NewServer(config, db, currencies)
+	if err != nil {
+		log.Fatal("can't create the server: ", err)
+	}
+
+	var workers sync.WaitGroup
+	workers.Add(2)
+	go func() {
+		defer workers.Done()
+		scheduler.NewWorker(db, config.SchedulerInterval).Run(ctx)
+	}()
+	go func() {
+		defer workers.Done()
+		scheduler.NewSweeper(db, db, config.HoldSweepInterval).Run(ctx)
+	}()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.Start(config.ServerAddress)
+	}()
+
+	// metrics are only served when their address is set, it must not be reachable from the outside
+	var metricsServer *http.Server
+	if len(config.MetricsAddress) > 0 {
+		metricsServer = api.NewMetricsServer(config.MetricsAddress)
+		go func() {
+			if err := metricsServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				log.Print("can't serve metrics: ", err)
+			}
+		}()
+	}
+
+	select {
+	case err = <-serverErr:
+		log.Fatal("can't start the server: ", err)
+	case <-ctx.Done():
+	}
+	stop()
+
+	log.Print("shutting down, draining requests in flight")
+	shutdownTimeout := config.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = defaultShutdownTimeout
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err = server.Shutdown(shutdownCtx); err != nil {
+		log.Print("can't drain requests in flight: ", err)
+	}
+	if metricsServer != nil {
+		if err = metricsServer.Shutdown(shutdownCtx); err != nil {
+			log.Print("can't stop the metrics server: ", err)
+		}
+	}
+
+	// a canceled tick rolls its transaction back, the database is closed once no one uses it
+	workers.Wait()
+	if err = conn.Close(); err != nil {
+		log.Print("can't close the database: ", err)
+	}
+}
+
+// verifyLedger prints the ledger report as JSON to stdout.
+// It returns the exit code, which is 1 when discrepancies were found so scripts can alert on it.
+func verifyLedger() int {
+	_, conn := setup()
+	defer conn.Close()
+
+	report, err := repo.NewStore(conn).VerifyLedger(context.Background())
+	if err != nil {
+		log.Fatal("can't verify the ledger: ", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(report); err != nil {
+		log.Fatal("can't write the report: ", err)
+	}
+
+	if !report.Consistent() {
+		return 1
+	}
+	return 0
+}
+
+func setup() (configs.Config, *sql.DB) {
 	config, err := configs.LoadConfig(".")
 	if err != nil {
 		log.Fatal("can't load config file", err)
@@ -23,11 +154,12 @@ func run() {
 		log.Fatalf("can't connect to %s database", config.DBDriver)
 	}
 
-	db := repo.NewStore(conn)
-	server := api.NewServer(db)
-
-	err = server.Start(config.ServerAddress)
-	if err != nil {
-		log.Fatalf("can't start the server")
+	// sql.Open doesn't connect, an unreachable database must fail the start rather than the first request
+	ctx, cancel := context.WithTimeout(context.Background(), dbPingTimeout)
+	defer cancel()
+	if err = conn.PingContext(ctx); err != nil {
+		log.Fatal("can't reach the database: ", err)
 	}
+
+	return config, conn
 }
@@ -1,11 +1,28 @@
 package configs
 
-import "github.com/spf13/viper"
+import (
+	"github.com/spf13/viper"
+	"time"
+)
 
 type Config struct {
-	DBDriver      string `mapstructure:"DB_DRIVER"`
-	DBSource      string `mapstructure:"DB_SOURCE"`
-	ServerAddress string `mapstructure:"ADDRESS"`
+	DBDriver             string        `mapstructure:"DB_DRIVER"`
+	DBSource             string        `mapstructure:"DB_SOURCE"`
+	ServerAddress        string        `mapstructure:"ADDRESS"`
+	MetricsAddress       string        `mapstructure:"METRICS_ADDRESS"`
+	TokenType            string        `mapstructure:"TOKEN_TYPE"`
+	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	CurrencyCacheTTL     time.Duration `mapstructure:"CURRENCY_CACHE_TTL"`
+	SchedulerInterval    time.Duration `mapstructure:"SCHEDULER_INTERVAL"`
+	HoldDuration         time.Duration `mapstructure:"HOLD_DURATION"`
+	HoldSweepInterval    time.Duration `mapstructure:"HOLD_SWEEP_INTERVAL"`
+	ReadTimeout          time.Duration `mapstructure:"READ_TIMEOUT"`
+	WriteTimeout         time.Duration `mapstructure:"WRITE_TIMEOUT"`
+	IdleTimeout          time.Duration `mapstructure:"IDLE_TIMEOUT"`
+	MaxHeaderBytes       int           `mapstructure:"MAX_HEADER_BYTES"`
+	ShutdownTimeout      time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
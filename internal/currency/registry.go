@@ -0,0 +1,141 @@
+package currency
+
+import (
+	"context"
+	"fmt"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	reloadTimeout = 5 * time.Second
+	// DefaultExponent is assumed for currencies missing in the registry
+	DefaultExponent = 2
+)
+
+// Source provides all currencies known to the bank
+type Source interface {
+	ListCurrencies(ctx context.Context) ([]repo.Currency, error)
+}
+
+// Registry caches the currencies table, so requests don't hit the database to validate a currency.
+// The cache is reloaded once it's older than ttl, so a currency added to the table
+// becomes available without a redeploy.
+type Registry struct {
+	source Source
+	ttl    time.Duration
+
+	mu         sync.RWMutex
+	currencies map[string]repo.Currency
+	enabled    []repo.Currency
+	loadedAt   time.Time
+
+	reloading atomic.Bool
+}
+
+// NewRegistry loads currencies from the source, so a misconfigured database is noticed at startup
+func NewRegistry(ctx context.Context, source Source, ttl time.Duration) (*Registry, error) {
+	registry := &Registry{
+		source: source,
+		ttl:    ttl,
+	}
+
+	if err := registry.Reload(ctx); err != nil {
+		return nil, fmt.Errorf("can't load currencies: %w", err)
+	}
+
+	return registry, nil
+}
+
+// Reload replaces cached currencies with the current content of the source
+func (r *Registry) Reload(ctx context.Context) error {
+	currencies, err := r.source.ListCurrencies(ctx)
+	if err != nil {
+		return err
+	}
+
+	byCode := make(map[string]repo.Currency, len(currencies))
+	enabled := make([]repo.Currency, 0, len(currencies))
+	for _, currency := range currencies {
+		byCode[currency.Code] = currency
+		if currency.Enabled {
+			enabled = append(enabled, currency)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.currencies = byCode
+	r.enabled = enabled
+	r.loadedAt = time.Now()
+
+	return nil
+}
+
+// Get returns an enabled currency by its ISO 4217 code
+func (r *Registry) Get(code string) (repo.Currency, bool) {
+	r.reloadIfStale()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	currency, ok := r.currencies[code]
+	return currency, ok && currency.Enabled
+}
+
+// Exponent returns the number of minor unit digits of the currency, disabled currencies included
+func (r *Registry) Exponent(code string) int32 {
+	r.reloadIfStale()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if currency, ok := r.currencies[code]; ok {
+		return currency.Exponent
+	}
+	return DefaultExponent
+}
+
+// IsSupported reports whether new accounts and transfers may use the currency
+func (r *Registry) IsSupported(code string) bool {
+	_, ok := r.Get(code)
+	return ok
+}
+
+// Enabled returns enabled currencies ordered as the source returned them
+func (r *Registry) Enabled() []repo.Currency {
+	r.reloadIfStale()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]repo.Currency(nil), r.enabled...)
+}
+
+// reloadIfStale lets only one caller reload the cache, the others keep using cached currencies meanwhile.
+// If the reload fails, the stale cache is used until the next ttl passes.
+func (r *Registry) reloadIfStale() {
+	r.mu.RLock()
+	stale := time.Since(r.loadedAt) > r.ttl
+	r.mu.RUnlock()
+
+	if !stale || !r.reloading.CompareAndSwap(false, true) {
+		return
+	}
+	defer r.reloading.Store(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), reloadTimeout)
+	defer cancel()
+
+	if err := r.Reload(ctx); err != nil {
+		log.Printf("can't reload currencies: %v", err)
+
+		r.mu.Lock()
+		r.loadedAt = time.Now()
+		r.mu.Unlock()
+	}
+}
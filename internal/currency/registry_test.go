@@ -0,0 +1,82 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type fakeSource struct {
+	currencies []repo.Currency
+	err        error
+	calls      int
+}
+
+func (s *fakeSource) ListCurrencies(_ context.Context) ([]repo.Currency, error) {
+	s.calls++
+	return s.currencies, s.err
+}
+
+func TestRegistry(t *testing.T) {
+	source := &fakeSource{
+		currencies: []repo.Currency{
+			{Code: util.EUR, Exponent: 2, Enabled: true},
+			{Code: util.UAH, Exponent: 2, Enabled: false},
+			{Code: util.USD, Exponent: 2, Enabled: true},
+		},
+	}
+
+	registry, err := NewRegistry(context.Background(), source, time.Hour)
+	require.NoError(t, err)
+
+	currency, ok := registry.Get(util.USD)
+	require.True(t, ok)
+	require.Equal(t, int32(2), currency.Exponent)
+
+	require.True(t, registry.IsSupported(util.EUR))
+	require.False(t, registry.IsSupported(util.UAH))
+	require.False(t, registry.IsSupported("XYZ"))
+
+	enabled := registry.Enabled()
+	require.Len(t, enabled, 2)
+	require.Equal(t, util.EUR, enabled[0].Code)
+	require.Equal(t, util.USD, enabled[1].Code)
+
+	// disabled currencies still have an exponent, because old accounts may use them
+	require.Equal(t, int32(2), registry.Exponent(util.UAH))
+	require.Equal(t, int32(DefaultExponent), registry.Exponent("XYZ"))
+
+	// cached currencies are used until the ttl passes
+	require.Equal(t, 1, source.calls)
+}
+
+func TestRegistryReload(t *testing.T) {
+	source := &fakeSource{
+		currencies: []repo.Currency{{Code: util.USD, Exponent: 2, Enabled: true}},
+	}
+
+	registry, err := NewRegistry(context.Background(), source, time.Nanosecond)
+	require.NoError(t, err)
+	require.False(t, registry.IsSupported(util.EUR))
+
+	source.currencies = append(source.currencies, repo.Currency{Code: util.EUR, Exponent: 2, Enabled: true})
+	time.Sleep(time.Millisecond)
+	require.True(t, registry.IsSupported(util.EUR))
+
+	// a failed reload keeps the cached currencies
+	source.err = errors.New("connection refused")
+	time.Sleep(time.Millisecond)
+	require.True(t, registry.IsSupported(util.USD))
+}
+
+func TestNewRegistryError(t *testing.T) {
+	source := &fakeSource{err: errors.New("connection refused")}
+
+	registry, err := NewRegistry(context.Background(), source, time.Hour)
+	require.Error(t, err)
+	require.Nil(t, registry)
+}
@@ -20,9 +20,22 @@ for no key update;
 
 -- name: ListAccounts :many
 select * from accounts
-order by id
-limit $2
-offset $1;
+where owner = sqlc.arg(owner)
+    and (
+        sqlc.arg(after_id)::bigint = 0
+        or (sqlc.arg(sort)::text = 'id' and id > sqlc.arg(after_id))
+        or (sqlc.arg(sort) = 'created_at' and (created_at, id) > (
+            select a.created_at, a.id from accounts a where a.id = sqlc.arg(after_id) and a.owner = sqlc.arg(owner)
+        ))
+        or (sqlc.arg(sort) = 'balance' and (balance, id) > (
+            select a.balance, a.id from accounts a where a.id = sqlc.arg(after_id) and a.owner = sqlc.arg(owner)
+        ))
+    )
+order by
+    case when sqlc.arg(sort) = 'created_at' then created_at end,
+    case when sqlc.arg(sort) = 'balance' then balance end,
+    id
+limit sqlc.arg(page_size);
 
 -- name: UpdateAccount :one
 update accounts
@@ -30,12 +43,30 @@ set balance = $2
 where id = $1
 returning *;
 
+-- name: UpdateAccountOverdraftLimit :one
+update accounts
+set overdraft_limit = sqlc.arg(overdraft_limit)
+where id = sqlc.arg(id)
+returning *;
+
+-- name: UpdateAccountStatus :one
+update accounts
+set status = sqlc.arg(status)
+where id = sqlc.arg(id)
+returning *;
+
 -- name: AddAccountBalance :one
 update accounts
 set balance = balance + sqlc.arg(amount)
 where id = sqlc.arg(id)
 returning *;
 
+-- name: AddAccountHold :one
+update accounts
+set held_amount = held_amount + sqlc.arg(amount)
+where id = sqlc.arg(id)
+returning *;
+
 -- name: DeleteAccount :exec
 delete from accounts
 where id = $1;
\ No newline at end of file
@@ -0,0 +1,8 @@
+-- name: ListCurrencies :many
+select * from currencies
+order by code;
+
+-- name: GetCurrency :one
+select * from currencies
+where code = $1
+limit 1;
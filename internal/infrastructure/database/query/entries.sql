@@ -1,7 +1,7 @@
 -- name: CreateEntry :one
 insert into entries(
-    account_id, amount
-) values ($1, $2)
+    account_id, amount, transfer_id, kind, description, external_reference, metadata
+) values ($1, $2, $3, $4, $5, $6, $7)
 returning *;
 
 -- name: GetEntry :one
@@ -22,10 +22,24 @@ limit $1
 offset $2;
 
 -- name: ListEntriesByAccountID :many
-select * from entries
-where account_id = $1
-limit $2
-offset $3;
+select id, account_id, amount, created_at, transfer_id, kind, description, external_reference, metadata, (
+        -- everything before the page: entries older than the period or up to the cursor
+        (
+            select coalesce(sum(amount), 0) from entries
+            where account_id = sqlc.arg(account_id)
+                and (
+                    created_at < sqlc.arg(from_time)
+                    or (created_at, id) <= (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint)
+                )
+        ) + sum(amount) over (order by created_at, id)
+    )::bigint as running_balance
+from entries
+where account_id = sqlc.arg(account_id)
+    and created_at >= sqlc.arg(from_time)
+    and created_at < sqlc.arg(to_time)
+    and (created_at, id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint)
+order by created_at, id
+limit sqlc.arg(page_size);
 
 -- name: UpdateEntry :one
 update entries
@@ -35,4 +49,26 @@ returning *;
 
 -- name: DeleteEntry :exec
 delete from entries
-where account_id = $1;
\ No newline at end of file
+where account_id = $1;
+
+-- name: GetAccountBalanceAt :one
+select coalesce(sum(amount), 0)::bigint as balance
+from entries
+where account_id = sqlc.arg(account_id) and created_at < sqlc.arg(before);
+
+-- name: ListStatementLines :many
+select e.id, e.amount, e.created_at, e.transfer_id, e.kind,
+    coalesce(c.id, 0)::bigint as counterparty_account_id,
+    coalesce(c.owner, '')::varchar as counterparty_owner,
+    e.description
+from entries as e
+    left join transfers as t on t.id = e.transfer_id
+    left join accounts as c on c.id = (
+        case when t.from_account_id = e.account_id then t.to_account_id else t.from_account_id end
+    )
+where e.account_id = sqlc.arg(account_id)
+    and e.created_at >= sqlc.arg(from_time)
+    and e.created_at < sqlc.arg(to_time)
+    and (e.created_at, e.id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint)
+order by e.created_at, e.id
+limit sqlc.arg(page_size);
@@ -0,0 +1,13 @@
+-- name: CreateIdempotencyKey :one
+insert into idempotency_keys (username, key, request_hash, response, expires_at)
+values ($1, $2, $3, $4, $5)
+returning *;
+
+-- name: GetIdempotencyKey :one
+select * from idempotency_keys
+where username = $1 and key = $2
+limit 1;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+delete from idempotency_keys
+where expires_at <= $1;
@@ -0,0 +1,20 @@
+-- name: ListBalanceMismatches :many
+select a.id as account_id, a.balance, coalesce(sum(e.amount), 0)::bigint as entries_sum
+from accounts a
+left join entries e on e.account_id = a.id
+group by a.id
+having a.balance <> coalesce(sum(e.amount), 0)
+order by a.id;
+
+-- name: ListUnbalancedTransfers :many
+select t.id as transfer_id, t.from_account_id, t.to_account_id, t.amount, t.to_amount, count(e.id) as entry_count
+from transfers t
+left join entries e on e.transfer_id = t.id
+group by t.id
+having (t.status <> 'posted' and count(e.id) <> 0)
+    or (t.status = 'posted' and (
+        count(e.id) <> 2
+        or count(e.id) filter (where e.account_id = t.from_account_id and e.amount = -t.amount) <> 1
+        or count(e.id) filter (where e.account_id = t.to_account_id and e.amount = t.to_amount) <> 1
+    ))
+order by t.id;
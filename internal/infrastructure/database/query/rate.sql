@@ -0,0 +1,12 @@
+-- name: CreateRate :one
+insert into rates (base_currency, quote_currency, rate, valid_from)
+values ($1, $2, $3, $4)
+on conflict (base_currency, quote_currency, valid_from) do update
+set rate = excluded.rate
+returning *;
+
+-- name: GetRate :one
+select * from rates
+where base_currency = $1 and quote_currency = $2 and valid_from <= now()
+order by valid_from desc
+limit 1;
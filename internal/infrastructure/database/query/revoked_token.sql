@@ -0,0 +1,15 @@
+-- name: RevokeToken :exec
+insert into revoked_tokens (id, username, expires_at)
+values ($1, $2, $3)
+on conflict (id) do nothing;
+
+-- name: IsTokenRevoked :one
+select exists(
+    select 1 from revoked_tokens
+    where id = $1
+);
+
+-- name: DeleteExpiredRevokedTokens :execrows
+-- an expired token fails verification anyway, so it doesn't have to be remembered
+delete from revoked_tokens
+where expires_at <= $1;
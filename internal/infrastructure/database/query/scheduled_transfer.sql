@@ -0,0 +1,64 @@
+-- name: CreateScheduledTransfer :one
+insert into scheduled_transfers(
+    owner, from_account_id, to_account_id, amount, currency, interval_unit, interval_count, next_run_at, end_date, start_at
+) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $8)
+returning *;
+
+-- name: GetScheduledTransfer :one
+select * from scheduled_transfers
+where id = $1
+limit 1;
+
+-- name: ListScheduledTransfers :many
+select * from scheduled_transfers
+where owner = sqlc.arg(owner) and id > sqlc.arg(after_id)
+order by id
+limit sqlc.arg(page_size);
+
+-- name: UpdateScheduledTransfer :one
+-- the runs are counted from the new next run, unless the update keeps the schedule itself
+update scheduled_transfers
+set amount = sqlc.arg(amount),
+    interval_unit = sqlc.arg(interval_unit),
+    interval_count = sqlc.arg(interval_count),
+    next_run_at = sqlc.arg(next_run_at),
+    end_date = sqlc.arg(end_date),
+    active = sqlc.arg(active),
+    start_at = case
+        when next_run_at = sqlc.arg(next_run_at) and interval_unit = sqlc.arg(interval_unit)
+            and interval_count = sqlc.arg(interval_count) then start_at
+        else sqlc.arg(next_run_at)
+    end
+where id = sqlc.arg(id)
+returning *;
+
+-- name: DeleteScheduledTransfer :exec
+delete from scheduled_transfers
+where id = $1;
+
+-- name: GetDueScheduledTransfer :one
+-- rows locked by other workers are skipped, so several server instances never run the same transfer
+select * from scheduled_transfers
+where active and next_run_at <= sqlc.arg(now)
+order by next_run_at
+limit 1
+for update skip locked;
+
+-- name: RescheduleScheduledTransfer :one
+update scheduled_transfers
+set next_run_at = sqlc.arg(next_run_at),
+    active = sqlc.arg(active)
+where id = sqlc.arg(id)
+returning *;
+
+-- name: CreateScheduledTransferRun :one
+insert into scheduled_transfer_runs(
+    scheduled_transfer_id, transfer_id, error
+) values ($1, $2, $3)
+returning *;
+
+-- name: ListScheduledTransferRuns :many
+select * from scheduled_transfer_runs
+where scheduled_transfer_id = sqlc.arg(scheduled_transfer_id)
+order by id desc
+limit sqlc.arg(page_size);
@@ -0,0 +1,25 @@
+-- name: CreateSession :one
+insert into sessions (id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at)
+values ($1, $2, $3, $4, $5, $6, $7)
+returning *;
+
+-- name: GetSession :one
+select * from sessions
+where id = $1
+limit 1;
+
+-- name: BlockSession :one
+update sessions
+set is_blocked = true
+where id = $1
+returning *;
+
+-- name: IsSessionBlocked :one
+select exists(
+    select 1 from sessions
+    where id = $1 and is_blocked
+);
+
+-- name: DeleteExpiredSessions :execrows
+delete from sessions
+where expires_at <= $1;
@@ -0,0 +1,78 @@
+-- name: UpsertTransferLimit :one
+insert into transfer_limits(
+    currency, max_amount, account_daily_limit, account_monthly_limit, user_daily_limit, user_monthly_limit
+) values ($1, $2, $3, $4, $5, $6)
+on conflict (currency) do update
+set max_amount = excluded.max_amount,
+    account_daily_limit = excluded.account_daily_limit,
+    account_monthly_limit = excluded.account_monthly_limit,
+    user_daily_limit = excluded.user_daily_limit,
+    user_monthly_limit = excluded.user_monthly_limit,
+    updated_at = now()
+returning *;
+
+-- name: UpsertUserTransferLimit :one
+insert into user_transfer_limits(
+    username, currency, max_amount, account_daily_limit, account_monthly_limit, user_daily_limit, user_monthly_limit
+) values ($1, $2, $3, $4, $5, $6, $7)
+on conflict (username, currency) do update
+set max_amount = excluded.max_amount,
+    account_daily_limit = excluded.account_daily_limit,
+    account_monthly_limit = excluded.account_monthly_limit,
+    user_daily_limit = excluded.user_daily_limit,
+    user_monthly_limit = excluded.user_monthly_limit,
+    updated_at = now()
+returning *;
+
+-- name: DeleteUserTransferLimit :exec
+delete from user_transfer_limits
+where username = $1 and currency = $2;
+
+-- name: GetEffectiveTransferLimit :one
+select c.code as currency,
+    coalesce(u.max_amount, d.max_amount, 0)::bigint as max_amount,
+    coalesce(u.account_daily_limit, d.account_daily_limit, 0)::bigint as account_daily_limit,
+    coalesce(u.account_monthly_limit, d.account_monthly_limit, 0)::bigint as account_monthly_limit,
+    coalesce(u.user_daily_limit, d.user_daily_limit, 0)::bigint as user_daily_limit,
+    coalesce(u.user_monthly_limit, d.user_monthly_limit, 0)::bigint as user_monthly_limit,
+    (u.username is not null)::boolean as overridden
+from currencies as c
+    left join transfer_limits as d on d.currency = c.code
+    left join user_transfer_limits as u on u.currency = c.code and u.username = sqlc.arg(username)
+where c.code = sqlc.arg(currency);
+
+-- name: ListEffectiveTransferLimits :many
+select c.code as currency,
+    coalesce(u.max_amount, d.max_amount, 0)::bigint as max_amount,
+    coalesce(u.account_daily_limit, d.account_daily_limit, 0)::bigint as account_daily_limit,
+    coalesce(u.account_monthly_limit, d.account_monthly_limit, 0)::bigint as account_monthly_limit,
+    coalesce(u.user_daily_limit, d.user_daily_limit, 0)::bigint as user_daily_limit,
+    coalesce(u.user_monthly_limit, d.user_monthly_limit, 0)::bigint as user_monthly_limit,
+    (u.username is not null)::boolean as overridden
+from currencies as c
+    left join transfer_limits as d on d.currency = c.code
+    left join user_transfer_limits as u on u.currency = c.code and u.username = sqlc.arg(username)
+order by c.code;
+
+-- name: GetOutgoingTotals :one
+select
+    coalesce(sum(o.amount) filter (
+        where o.account_id = sqlc.arg(account_id) and o.created_at >= sqlc.arg(day_start)
+    ), 0)::bigint as account_daily,
+    coalesce(sum(o.amount) filter (where o.account_id = sqlc.arg(account_id)), 0)::bigint as account_monthly,
+    coalesce(sum(o.amount) filter (where o.created_at >= sqlc.arg(day_start)), 0)::bigint as user_daily,
+    coalesce(sum(o.amount), 0)::bigint as user_monthly
+from (
+    select t.from_account_id as account_id, t.amount, t.created_at
+    from transfers as t
+    where t.reversal_of is null
+        and t.status <> 'voided'
+    union all
+    select e.account_id, -e.amount as amount, e.created_at
+    from entries as e
+    where e.kind = 'withdrawal'
+) as o
+    join accounts as a on a.id = o.account_id
+where a.owner = sqlc.arg(owner)
+    and a.currency = sqlc.arg(currency)
+    and o.created_at >= sqlc.arg(month_start);
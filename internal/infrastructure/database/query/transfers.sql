@@ -1,13 +1,26 @@
 -- name: CreateTransfer :one
-insert into transfers(from_account_id, to_account_id, amount)
-values ($1, $2, $3)
+insert into transfers(
+    from_account_id, to_account_id, amount, to_amount, rate, reversal_of, description, external_reference, metadata
+) values ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 returning *;
 
 -- name: ListTransfers :many
 select * from transfers
-where from_account_id = $1 OR to_account_id = $2
-limit $3
-offset $4;
+where (
+        (sqlc.arg(outgoing)::boolean and from_account_id = sqlc.arg(account_id))
+        or (sqlc.arg(incoming)::boolean and to_account_id = sqlc.arg(account_id))
+    )
+    and created_at >= sqlc.arg(from_time)
+    and created_at < sqlc.arg(to_time)
+    -- amounts are compared in the currency of the account: incoming transfers credited it with to_amount
+    and (case when to_account_id = sqlc.arg(account_id) then to_amount else amount end)
+        between sqlc.arg(min_amount)::bigint and sqlc.arg(max_amount)::bigint
+    and strpos(lower(description), lower(sqlc.arg(description)::varchar)) > 0
+    and (sqlc.arg(external_reference)::varchar = '' or external_reference = sqlc.arg(external_reference))
+    and metadata @> sqlc.arg(metadata)::jsonb
+    and (created_at, id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint)
+order by created_at, id
+limit sqlc.arg(page_size);
 
 -- name: GetTransfer :one
 select *
@@ -15,7 +28,35 @@ from transfers
 where id = $1
 limit 1;
 
+-- name: GetTransferForUpdate :one
+select *
+from transfers
+where id = $1
+limit 1
+for no key update;
 
+-- name: GetReversedAmounts :one
+select coalesce(sum(amount), 0)::bigint as amount, coalesce(sum(to_amount), 0)::bigint as to_amount
+from transfers
+where reversal_of = $1;
 
+-- name: CreatePendingTransfer :one
+insert into transfers(
+    from_account_id, to_account_id, amount, to_amount, rate, description, external_reference, metadata, status, expires_at
+) values ($1, $2, $3, $4, $5, $6, $7, $8, 'pending', $9)
+returning *;
 
+-- name: UpdateTransferStatus :one
+update transfers
+set status = sqlc.arg(status)
+where id = sqlc.arg(id)
+returning *;
 
+-- name: GetExpiredPendingTransfer :one
+select *
+from transfers
+where status = 'pending'
+    and expires_at <= sqlc.arg(now)::timestamptz
+order by expires_at
+limit 1
+for no key update skip locked;
@@ -13,7 +13,7 @@ const addAccountBalance = `-- name: AddAccountBalance :one
 update accounts
 set balance = balance + $1
 where id = $2
-returning id, owner, balance, currency, created_at
+returning id, owner, balance, currency, created_at, overdraft_limit, status, held_amount, available_balance
 `
 
 type AddAccountBalanceParams struct {
@@ -30,6 +30,39 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldAmount,
+		&i.AvailableBalance,
+	)
+	return i, err
+}
+
+const addAccountHold = `-- name: AddAccountHold :one
+update accounts
+set held_amount = held_amount + $1
+where id = $2
+returning id, owner, balance, currency, created_at, overdraft_limit, status, held_amount, available_balance
+`
+
+type AddAccountHoldParams struct {
+	Amount int64 `json:"amount"`
+	ID     int64 `json:"id"`
+}
+
+func (q *Queries) AddAccountHold(ctx context.Context, arg AddAccountHoldParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, addAccountHold, arg.Amount, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldAmount,
+		&i.AvailableBalance,
 	)
 	return i, err
 }
@@ -43,7 +76,7 @@ VALUES
 (
     $1, $2, $3
 )
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, status, held_amount, available_balance
 `
 
 type CreateAccountParams struct {
@@ -61,6 +94,10 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldAmount,
+		&i.AvailableBalance,
 	)
 	return i, err
 }
@@ -76,7 +113,7 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, overdraft_limit, status, held_amount, available_balance FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
@@ -89,12 +126,16 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldAmount,
+		&i.AvailableBalance,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, overdraft_limit, status, held_amount, available_balance FROM accounts
 WHERE id = $1 limit 1
 for no key update
 `
@@ -108,24 +149,48 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldAmount,
+		&i.AvailableBalance,
 	)
 	return i, err
 }
 
 const listAccounts = `-- name: ListAccounts :many
-select id, owner, balance, currency, created_at from accounts
-order by id
-limit $2
-offset $1
+select id, owner, balance, currency, created_at, overdraft_limit, status, held_amount, available_balance from accounts
+where owner = $1
+    and (
+        $2::bigint = 0
+        or ($3::text = 'id' and id > $2)
+        or ($3 = 'created_at' and (created_at, id) > (
+            select a.created_at, a.id from accounts a where a.id = $2 and a.owner = $1
+        ))
+        or ($3 = 'balance' and (balance, id) > (
+            select a.balance, a.id from accounts a where a.id = $2 and a.owner = $1
+        ))
+    )
+order by
+    case when $3 = 'created_at' then created_at end,
+    case when $3 = 'balance' then balance end,
+    id
+limit $4
 `
 
 type ListAccountsParams struct {
-	Offset int32 `json:"offset"`
-	Limit  int32 `json:"limit"`
+	Owner    string `json:"owner"`
+	AfterID  int64  `json:"after_id"`
+	Sort     string `json:"sort"`
+	PageSize int32  `json:"page_size"`
 }
 
 func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
-	rows, err := q.db.QueryContext(ctx, listAccounts, arg.Offset, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, listAccounts,
+		arg.Owner,
+		arg.AfterID,
+		arg.Sort,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -139,6 +204,10 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.OverdraftLimit,
+			&i.Status,
+			&i.HeldAmount,
+			&i.AvailableBalance,
 		); err != nil {
 			return nil, err
 		}
@@ -157,7 +226,7 @@ const updateAccount = `-- name: UpdateAccount :one
 update accounts
 set balance = $2
 where id = $1
-returning id, owner, balance, currency, created_at
+returning id, owner, balance, currency, created_at, overdraft_limit, status, held_amount, available_balance
 `
 
 type UpdateAccountParams struct {
@@ -174,6 +243,68 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldAmount,
+		&i.AvailableBalance,
+	)
+	return i, err
+}
+
+const updateAccountOverdraftLimit = `-- name: UpdateAccountOverdraftLimit :one
+update accounts
+set overdraft_limit = $1
+where id = $2
+returning id, owner, balance, currency, created_at, overdraft_limit, status, held_amount, available_balance
+`
+
+type UpdateAccountOverdraftLimitParams struct {
+	OverdraftLimit int64 `json:"overdraft_limit"`
+	ID             int64 `json:"id"`
+}
+
+func (q *Queries) UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountOverdraftLimit, arg.OverdraftLimit, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldAmount,
+		&i.AvailableBalance,
+	)
+	return i, err
+}
+
+const updateAccountStatus = `-- name: UpdateAccountStatus :one
+update accounts
+set status = $1
+where id = $2
+returning id, owner, balance, currency, created_at, overdraft_limit, status, held_amount, available_balance
+`
+
+type UpdateAccountStatusParams struct {
+	Status string `json:"status"`
+	ID     int64  `json:"id"`
+}
+
+func (q *Queries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountStatus, arg.Status, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldAmount,
+		&i.AvailableBalance,
 	)
 	return i, err
 }
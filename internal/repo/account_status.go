@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	AccountStatusActive = "active"
+	AccountStatusFrozen = "frozen"
+	AccountStatusClosed = "closed"
+)
+
+// accountStatusTransitions lists the statuses each status can go to, closed is final
+var accountStatusTransitions = map[string][]string{
+	AccountStatusActive: {AccountStatusFrozen, AccountStatusClosed},
+	AccountStatusFrozen: {AccountStatusActive},
+}
+
+type ChangeAccountStatusTxParams struct {
+	AccountID int64  `json:"account_id"`
+	Status    string `json:"status"`
+}
+
+// ChangeAccountStatusTx moves the account to the status. The account is locked,
+// so a transfer can't change the balance between the zero balance check and the closing.
+func (s *SQLStore) ChangeAccountStatusTx(ctx context.Context, arg ChangeAccountStatusTxParams) (Account, error) {
+	var result Account
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if !canChangeStatus(account.Status, arg.Status) {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, account.Status, arg.Status)
+		}
+		if arg.Status == AccountStatusClosed && account.Balance != 0 {
+			return ErrBalanceNotZero
+		}
+		if arg.Status == AccountStatusClosed && account.HeldAmount != 0 {
+			return fmt.Errorf("%w: %d held by pending transfers", ErrBalanceNotZero, account.HeldAmount)
+		}
+
+		result, err = q.UpdateAccountStatus(ctx, UpdateAccountStatusParams{
+			Status: arg.Status,
+			ID:     arg.AccountID,
+		})
+		return err
+	})
+
+	return result, wrapError(err)
+}
+
+func canChangeStatus(from, to string) bool {
+	for _, status := range accountStatusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// requireActive must be called on locked accounts, otherwise the status could change right after the check
+func requireActive(account Account) error {
+	if account.Status != AccountStatusActive {
+		return fmt.Errorf("%w: account %d is %s", ErrAccountNotActive, account.ID, account.Status)
+	}
+	return nil
+}
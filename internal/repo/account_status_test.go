@@ -0,0 +1,75 @@
+package repo
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestStore_ChangeAccountStatusTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
+
+	frozen, err := store.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusTxParams{
+		AccountID: account2.ID,
+		Status:    AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusFrozen, frozen.Status)
+
+	// frozen accounts can't receive money and can't be closed
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+
+	_, err = store.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusTxParams{
+		AccountID: account2.ID,
+		Status:    AccountStatusClosed,
+	})
+	require.ErrorIs(t, err, ErrInvalidStatusTransition)
+
+	active, err := store.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusTxParams{
+		AccountID: account2.ID,
+		Status:    AccountStatusActive,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusActive, active.Status)
+
+	// only empty accounts can be closed
+	_, err = store.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusTxParams{
+		AccountID: account2.ID,
+		Status:    AccountStatusClosed,
+	})
+	require.ErrorIs(t, err, ErrBalanceNotZero)
+
+	_, err = store.WithdrawTx(context.Background(), BalanceChangeTxParams{
+		AccountID: account2.ID,
+		Amount:    active.Balance,
+	})
+	require.NoError(t, err)
+
+	closed, err := store.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusTxParams{
+		AccountID: account2.ID,
+		Status:    AccountStatusClosed,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusClosed, closed.Status)
+
+	// closed is final, and the owner may open a new account in the same currency
+	_, err = store.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusTxParams{
+		AccountID: account2.ID,
+		Status:    AccountStatusActive,
+	})
+	require.ErrorIs(t, err, ErrInvalidStatusTransition)
+
+	_, err = store.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    account2.Owner,
+		Currency: account2.Currency,
+	})
+	require.NoError(t, err)
+}
@@ -24,6 +24,7 @@ func createRandomAccount(t *testing.T) Account {
 	require.Equal(t, arg.Owner, account.Owner)
 	require.Equal(t, arg.Balance, account.Balance)
 	require.Equal(t, arg.Currency, account.Currency)
+	require.Equal(t, AccountStatusActive, account.Status)
 
 	require.NotZero(t, account.ID)
 	require.NotZero(t, account.CreatedAt)
@@ -80,20 +81,48 @@ func TestDeleteAccount(t *testing.T) {
 }
 
 func TestListAccounts(t *testing.T) {
-	for i := 0; i < 10; i++ {
-		createRandomAccount(t)
+	owner := createRandomUser(t)
+
+	// one account per currency, the owner can't have two accounts in the same currency
+	currencies := []string{util.USD, util.EUR, util.UAH}
+	for i, currency := range currencies {
+		_, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+			Owner:    owner.Username,
+			Balance:  int64(300 - i*100),
+			Currency: currency,
+		})
+		require.NoError(t, err)
 	}
 
-	arg := ListAccountsParams{
-		Limit:  5,
-		Offset: 5,
-	}
-
-	accounts, err := testQueries.ListAccounts(context.Background(), arg)
+	firstPage, err := testQueries.ListAccounts(context.Background(), ListAccountsParams{
+		Owner:    owner.Username,
+		Sort:     "balance",
+		PageSize: 2,
+	})
 	require.NoError(t, err)
-	require.Len(t, accounts, 5)
-
-	for _, account := range accounts {
-		require.NotEmpty(t, account)
+	require.Len(t, firstPage, 2)
+	require.Equal(t, int64(100), firstPage[0].Balance)
+	require.Equal(t, int64(200), firstPage[1].Balance)
+
+	secondPage, err := testQueries.ListAccounts(context.Background(), ListAccountsParams{
+		Owner:    owner.Username,
+		AfterID:  firstPage[1].ID,
+		Sort:     "balance",
+		PageSize: 2,
+	})
+	require.NoError(t, err)
+	require.Len(t, secondPage, 1)
+	require.Equal(t, int64(300), secondPage[0].Balance)
+
+	byID, err := testQueries.ListAccounts(context.Background(), ListAccountsParams{
+		Owner:    owner.Username,
+		AfterID:  firstPage[1].ID,
+		Sort:     "id",
+		PageSize: 10,
+	})
+	require.NoError(t, err)
+	for _, account := range byID {
+		require.Equal(t, owner.Username, account.Owner)
+		require.Greater(t, account.ID, firstPage[1].ID)
 	}
 }
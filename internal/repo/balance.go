@@ -0,0 +1,110 @@
+package repo
+
+import (
+	"context"
+	"time"
+)
+
+// kinds of entries, an entry of any other kind than transfer isn't linked to a transfer
+const (
+	EntryKindTransfer   = "transfer"
+	EntryKindDeposit    = "deposit"
+	EntryKindWithdrawal = "withdrawal"
+	EntryKindAdjustment = "adjustment"
+)
+
+type BalanceChangeTxParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+}
+
+type AdjustBalanceTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// the balance the account must end up with
+	Balance int64 `json:"balance"`
+}
+
+type BalanceChangeTxResult struct {
+	Account Account `json:"account"`
+	Entry   Entry   `json:"entry"`
+}
+
+// DepositTx adds money to the account and records it as a deposit entry
+func (s *SQLStore) DepositTx(ctx context.Context, arg BalanceChangeTxParams) (BalanceChangeTxResult, error) {
+	return s.changeBalanceTx(ctx, arg.AccountID, EntryKindDeposit, func(_ *Queries, account Account) (int64, error) {
+		if err := requireActive(account); err != nil {
+			return 0, err
+		}
+		return arg.Amount, nil
+	})
+}
+
+// WithdrawTx takes money from the account within its overdraft limit and records it as a withdrawal entry.
+// Withdrawals count against the transfer limits of the owner like outgoing transfers.
+func (s *SQLStore) WithdrawTx(ctx context.Context, arg BalanceChangeTxParams) (BalanceChangeTxResult, error) {
+	return s.changeBalanceTx(ctx, arg.AccountID, EntryKindWithdrawal, func(q *Queries, account Account) (int64, error) {
+		if err := requireActive(account); err != nil {
+			return 0, err
+		}
+		if err := checkLimits(ctx, q, account, arg.Amount, time.Now()); err != nil {
+			return 0, err
+		}
+		if account.AvailableBalance+account.OverdraftLimit < arg.Amount {
+			return 0, ErrInsufficientFunds
+		}
+		return -arg.Amount, nil
+	})
+}
+
+// AdjustBalanceTx sets the balance of the account. The difference with the current balance is recorded
+// as an adjustment entry, so the balance still is the sum of the account entries.
+// Admins may adjust frozen accounts, but closed accounts must stay at zero.
+func (s *SQLStore) AdjustBalanceTx(ctx context.Context, arg AdjustBalanceTxParams) (BalanceChangeTxResult, error) {
+	return s.changeBalanceTx(ctx, arg.AccountID, EntryKindAdjustment, func(_ *Queries, account Account) (int64, error) {
+		if account.Status == AccountStatusClosed {
+			return 0, requireActive(account)
+		}
+		return arg.Balance - account.Balance, nil
+	})
+}
+
+// changeBalanceTx locks the account, so amountOf sees the balance the entry amount is added to
+func (s *SQLStore) changeBalanceTx(
+	ctx context.Context,
+	accountID int64,
+	kind string,
+	amountOf func(q *Queries, account Account) (int64, error),
+) (BalanceChangeTxResult, error) {
+	var result BalanceChangeTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		result = BalanceChangeTxResult{}
+		account, err := q.GetAccountForUpdate(ctx, accountID)
+		if err != nil {
+			return err
+		}
+
+		amount, err := amountOf(q, account)
+		if err != nil {
+			return err
+		}
+
+		result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID: accountID,
+			Amount:    amount,
+			Kind:      kind,
+			Metadata:  emptyMetadata,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			Amount: amount,
+			ID:     accountID,
+		})
+		return err
+	})
+
+	return result, wrapError(err)
+}
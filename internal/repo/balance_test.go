@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestStore_DepositTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createFundedAccount(t, 1000)
+
+	result, err := store.DepositTx(context.Background(), BalanceChangeTxParams{
+		AccountID: account.ID,
+		Amount:    250,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1250), result.Account.Balance)
+	require.Equal(t, account.ID, result.Entry.AccountID)
+	require.Equal(t, int64(250), result.Entry.Amount)
+	require.Equal(t, EntryKindDeposit, result.Entry.Kind)
+	require.Nil(t, result.Entry.TransferID)
+}
+
+func TestStore_WithdrawTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createFundedAccount(t, 1000)
+
+	result, err := store.WithdrawTx(context.Background(), BalanceChangeTxParams{
+		AccountID: account.ID,
+		Amount:    400,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(600), result.Account.Balance)
+	require.Equal(t, int64(-400), result.Entry.Amount)
+	require.Equal(t, EntryKindWithdrawal, result.Entry.Kind)
+
+	// nothing is written when the funds are insufficient
+	_, err = store.WithdrawTx(context.Background(), BalanceChangeTxParams{
+		AccountID: account.ID,
+		Amount:    601,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	account, err = store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(600), account.Balance)
+}
+
+func TestStore_AdjustBalanceTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createFundedAccount(t, 1000)
+
+	result, err := store.AdjustBalanceTx(context.Background(), AdjustBalanceTxParams{
+		AccountID: account.ID,
+		Balance:   700,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(700), result.Account.Balance)
+	require.Equal(t, int64(-300), result.Entry.Amount)
+	require.Equal(t, EntryKindAdjustment, result.Entry.Kind)
+
+	_, err = store.AdjustBalanceTx(context.Background(), AdjustBalanceTxParams{AccountID: -1})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,28 @@
+package repo
+
+import (
+	"context"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestQueries_ListCurrencies(t *testing.T) {
+	currencies, err := testQueries.ListCurrencies(context.Background())
+	require.NoError(t, err)
+
+	// currencies seeded by migrations
+	codes := make([]string, 0, len(currencies))
+	for _, currency := range currencies {
+		codes = append(codes, currency.Code)
+	}
+	require.Subset(t, codes, []string{util.EUR, util.UAH, util.USD})
+}
+
+func TestQueries_GetCurrency(t *testing.T) {
+	currency, err := testQueries.GetCurrency(context.Background(), util.USD)
+	require.NoError(t, err)
+	require.Equal(t, util.USD, currency.Code)
+	require.Equal(t, int32(2), currency.Exponent)
+	require.True(t, currency.Enabled)
+}
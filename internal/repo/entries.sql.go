@@ -7,28 +7,48 @@ package repo
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 )
 
 const createEntry = `-- name: CreateEntry :one
 insert into entries(
-    account_id, amount
-) values ($1, $2)
-returning id, account_id, amount, created_at
+    account_id, amount, transfer_id, kind, description, external_reference, metadata
+) values ($1, $2, $3, $4, $5, $6, $7)
+returning id, account_id, amount, created_at, transfer_id, kind, description, external_reference, metadata
 `
 
 type CreateEntryParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
+	AccountID         int64           `json:"account_id"`
+	Amount            int64           `json:"amount"`
+	TransferID        *int64          `json:"transfer_id"`
+	Kind              string          `json:"kind"`
+	Description       string          `json:"description"`
+	ExternalReference string          `json:"external_reference"`
+	Metadata          json.RawMessage `json:"metadata"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createEntry,
+		arg.AccountID,
+		arg.Amount,
+		arg.TransferID,
+		arg.Kind,
+		arg.Description,
+		arg.ExternalReference,
+		arg.Metadata,
+	)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.Kind,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
 	)
 	return i, err
 }
@@ -43,8 +63,26 @@ func (q *Queries) DeleteEntry(ctx context.Context, accountID int64) error {
 	return err
 }
 
+const getAccountBalanceAt = `-- name: GetAccountBalanceAt :one
+select coalesce(sum(amount), 0)::bigint as balance
+from entries
+where account_id = $1 and created_at < $2
+`
+
+type GetAccountBalanceAtParams struct {
+	AccountID int64     `json:"account_id"`
+	Before    time.Time `json:"before"`
+}
+
+func (q *Queries) GetAccountBalanceAt(ctx context.Context, arg GetAccountBalanceAtParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getAccountBalanceAt, arg.AccountID, arg.Before)
+	var balance int64
+	err := row.Scan(&balance)
+	return balance, err
+}
+
 const getEntry = `-- name: GetEntry :one
-select id, account_id, amount, created_at
+select id, account_id, amount, created_at, transfer_id, kind, description, external_reference, metadata
 from entries
 where id = $1
 limit 1
@@ -58,12 +96,17 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.Kind,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
 	)
 	return i, err
 }
 
 const getEntryByAccountID = `-- name: GetEntryByAccountID :one
-select id, account_id, amount, created_at from entries
+select id, account_id, amount, created_at, transfer_id, kind, description, external_reference, metadata from entries
 where account_id = $1
 limit 1
 `
@@ -76,12 +119,17 @@ func (q *Queries) GetEntryByAccountID(ctx context.Context, accountID int64) (Ent
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.Kind,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
 	)
 	return i, err
 }
 
 const listEntries = `-- name: ListEntries :many
-select id, account_id, amount, created_at from entries
+select id, account_id, amount, created_at, transfer_id, kind, description, external_reference, metadata from entries
 order by id
 limit $1
 offset $2
@@ -106,6 +154,11 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.TransferID,
+			&i.Kind,
+			&i.Description,
+			&i.ExternalReference,
+			&i.Metadata,
 		); err != nil {
 			return nil, err
 		}
@@ -121,32 +174,152 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 }
 
 const listEntriesByAccountID = `-- name: ListEntriesByAccountID :many
-select id, account_id, amount, created_at from entries
+select id, account_id, amount, created_at, transfer_id, kind, description, external_reference, metadata, (
+        -- everything before the page: entries older than the period or up to the cursor
+        (
+            select coalesce(sum(amount), 0) from entries
+            where account_id = $1
+                and (
+                    created_at < $2
+                    or (created_at, id) <= ($3::timestamptz, $4::bigint)
+                )
+        ) + sum(amount) over (order by created_at, id)
+    )::bigint as running_balance
+from entries
 where account_id = $1
-limit $2
-offset $3
+    and created_at >= $2
+    and created_at < $5
+    and (created_at, id) > ($3::timestamptz, $4::bigint)
+order by created_at, id
+limit $6
 `
 
 type ListEntriesByAccountIDParams struct {
-	AccountID int64 `json:"account_id"`
-	Limit     int32 `json:"limit"`
-	Offset    int32 `json:"offset"`
+	AccountID      int64     `json:"account_id"`
+	FromTime       time.Time `json:"from_time"`
+	AfterCreatedAt time.Time `json:"after_created_at"`
+	AfterID        int64     `json:"after_id"`
+	ToTime         time.Time `json:"to_time"`
+	PageSize       int32     `json:"page_size"`
 }
 
-func (q *Queries) ListEntriesByAccountID(ctx context.Context, arg ListEntriesByAccountIDParams) ([]Entry, error) {
-	rows, err := q.db.QueryContext(ctx, listEntriesByAccountID, arg.AccountID, arg.Limit, arg.Offset)
+type ListEntriesByAccountIDRow struct {
+	ID                int64           `json:"id"`
+	AccountID         int64           `json:"account_id"`
+	Amount            int64           `json:"amount"`
+	CreatedAt         time.Time       `json:"created_at"`
+	TransferID        *int64          `json:"transfer_id"`
+	Kind              string          `json:"kind"`
+	Description       string          `json:"description"`
+	ExternalReference string          `json:"external_reference"`
+	Metadata          json.RawMessage `json:"metadata"`
+	RunningBalance    int64           `json:"running_balance"`
+}
+
+func (q *Queries) ListEntriesByAccountID(ctx context.Context, arg ListEntriesByAccountIDParams) ([]ListEntriesByAccountIDRow, error) {
+	rows, err := q.db.QueryContext(ctx, listEntriesByAccountID,
+		arg.AccountID,
+		arg.FromTime,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.ToTime,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Entry{}
+	items := []ListEntriesByAccountIDRow{}
 	for rows.Next() {
-		var i Entry
+		var i ListEntriesByAccountIDRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.TransferID,
+			&i.Kind,
+			&i.Description,
+			&i.ExternalReference,
+			&i.Metadata,
+			&i.RunningBalance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStatementLines = `-- name: ListStatementLines :many
+select e.id, e.amount, e.created_at, e.transfer_id, e.kind,
+    coalesce(c.id, 0)::bigint as counterparty_account_id,
+    coalesce(c.owner, '')::varchar as counterparty_owner,
+    e.description
+from entries as e
+    left join transfers as t on t.id = e.transfer_id
+    left join accounts as c on c.id = (
+        case when t.from_account_id = e.account_id then t.to_account_id else t.from_account_id end
+    )
+where e.account_id = $1
+    and e.created_at >= $2
+    and e.created_at < $3
+    and (e.created_at, e.id) > ($4::timestamptz, $5::bigint)
+order by e.created_at, e.id
+limit $6
+`
+
+type ListStatementLinesParams struct {
+	AccountID      int64     `json:"account_id"`
+	FromTime       time.Time `json:"from_time"`
+	ToTime         time.Time `json:"to_time"`
+	AfterCreatedAt time.Time `json:"after_created_at"`
+	AfterID        int64     `json:"after_id"`
+	PageSize       int32     `json:"page_size"`
+}
+
+type ListStatementLinesRow struct {
+	ID                    int64     `json:"id"`
+	Amount                int64     `json:"amount"`
+	CreatedAt             time.Time `json:"created_at"`
+	TransferID            *int64    `json:"transfer_id"`
+	Kind                  string    `json:"kind"`
+	CounterpartyAccountID int64     `json:"counterparty_account_id"`
+	CounterpartyOwner     string    `json:"counterparty_owner"`
+	Description           string    `json:"description"`
+}
+
+func (q *Queries) ListStatementLines(ctx context.Context, arg ListStatementLinesParams) ([]ListStatementLinesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listStatementLines,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListStatementLinesRow{}
+	for rows.Next() {
+		var i ListStatementLinesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
+			&i.Kind,
+			&i.CounterpartyAccountID,
+			&i.CounterpartyOwner,
+			&i.Description,
 		); err != nil {
 			return nil, err
 		}
@@ -165,7 +338,7 @@ const updateEntry = `-- name: UpdateEntry :one
 update entries
 set amount = $2
 where account_id = $1
-returning id, account_id, amount, created_at
+returning id, account_id, amount, created_at, transfer_id, kind, description, external_reference, metadata
 `
 
 type UpdateEntryParams struct {
@@ -181,6 +354,11 @@ func (q *Queries) UpdateEntry(ctx context.Context, arg UpdateEntryParams) (Entry
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.Kind,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
 	)
 	return i, err
 }
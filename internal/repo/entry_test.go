@@ -3,6 +3,7 @@ package repo
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"github.com/max-rodziyevsky/go-simple-bank/util"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -15,6 +16,8 @@ func createRandomEntry(t *testing.T) Entry {
 	arg := CreateEntryParams{
 		AccountID: account.ID,
 		Amount:    util.RandomMoney(),
+		Kind:      EntryKindDeposit,
+		Metadata:  emptyMetadata,
 	}
 
 	entry, err := testQueries.CreateEntry(context.Background(), arg)
@@ -23,6 +26,7 @@ func createRandomEntry(t *testing.T) Entry {
 
 	require.Equal(t, arg.AccountID, entry.AccountID)
 	require.Equal(t, arg.Amount, entry.Amount)
+	require.Equal(t, arg.Kind, entry.Kind)
 
 	require.NotZero(t, entry.ID)
 	require.NotZero(t, entry.CreatedAt)
@@ -81,31 +85,66 @@ func TestQueries_ListEntries(t *testing.T) {
 func TestQueries_ListEntriesByAccountID(t *testing.T) {
 	account := createRandomAccount(t)
 
+	var balances []int64
+	var balance int64
 	for i := 0; i < 10; i++ {
 		arg := CreateEntryParams{
-			AccountID: account.ID,
-			Amount:    util.RandomMoney(),
+			AccountID:         account.ID,
+			Amount:            util.RandomInt(-1000, 1000),
+			Kind:              EntryKindAdjustment,
+			Description:       "correction",
+			ExternalReference: "TICKET-7",
+			Metadata:          json.RawMessage(`{"ticket": "7"}`),
 		}
 
 		entry, err := testQueries.CreateEntry(context.Background(), arg)
 		require.NoError(t, err)
 		require.NotEmpty(t, entry)
+
+		balance += entry.Amount
+		balances = append(balances, balance)
 	}
 
-	arg2 := ListEntriesByAccountIDParams{
+	arg := ListEntriesByAccountIDParams{
 		AccountID: account.ID,
-		Limit:     5,
-		Offset:    5,
+		ToTime:    time.Now().Add(time.Minute),
+		PageSize:  5,
 	}
 
-	entries, err := testQueries.ListEntriesByAccountID(context.Background(), arg2)
+	page1, err := testQueries.ListEntriesByAccountID(context.Background(), arg)
 	require.NoError(t, err)
-	require.Len(t, entries, 5)
+	require.Len(t, page1, 5)
 
-	for _, entry := range entries {
-		require.NotEmpty(t, entry)
-		require.Equal(t, arg2.AccountID, entry.AccountID)
+	// the running balance of the next page continues from the last line of the previous one
+	last := page1[len(page1)-1]
+	arg.AfterCreatedAt = last.CreatedAt
+	arg.AfterID = last.ID
+
+	page2, err := testQueries.ListEntriesByAccountID(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, page2, 5)
+
+	for i, entry := range append(page1, page2...) {
+		require.Equal(t, account.ID, entry.AccountID)
+		require.Equal(t, EntryKindAdjustment, entry.Kind)
+		require.Equal(t, "correction", entry.Description)
+		require.Equal(t, "TICKET-7", entry.ExternalReference)
+		require.JSONEq(t, `{"ticket": "7"}`, string(entry.Metadata))
+		require.Equal(t, balances[i], entry.RunningBalance)
+	}
+
+	// entries before the period still count in the running balance
+	arg = ListEntriesByAccountIDParams{
+		AccountID: account.ID,
+		FromTime:  page2[0].CreatedAt,
+		ToTime:    time.Now().Add(time.Minute),
+		PageSize:  10,
 	}
+
+	entries, err := testQueries.ListEntriesByAccountID(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	require.Equal(t, balances[len(balances)-1], entries[len(entries)-1].RunningBalance)
 }
 
 func TestQueries_UpdateEntry(t *testing.T) {
@@ -136,3 +175,41 @@ func TestQueries_DeleteEntry(t *testing.T) {
 	require.EqualError(t, err, sql.ErrNoRows.Error())
 	require.Empty(t, entry2)
 }
+
+func TestQueries_ListStatementLines(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 100)
+	account2 := createFundedAccount(t, 100)
+	start := time.Now()
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, result.Transfer.ID, *result.FromEntry.TransferID)
+	require.Equal(t, result.Transfer.ID, *result.ToEntry.TransferID)
+
+	lines, err := testQueries.ListStatementLines(context.Background(), ListStatementLinesParams{
+		AccountID: account1.ID,
+		FromTime:  start.Add(-time.Minute),
+		ToTime:    time.Now().Add(time.Minute),
+		PageSize:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, lines, 1)
+	require.Equal(t, result.FromEntry.ID, lines[0].ID)
+	require.Equal(t, int64(-10), lines[0].Amount)
+	require.Equal(t, EntryKindTransfer, lines[0].Kind)
+	require.Equal(t, account2.ID, lines[0].CounterpartyAccountID)
+	require.Equal(t, account2.Owner, lines[0].CounterpartyOwner)
+
+	opening, err := testQueries.GetAccountBalanceAt(context.Background(), GetAccountBalanceAtParams{
+		AccountID: account1.ID,
+		Before:    time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(-10), opening)
+}
@@ -0,0 +1,346 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"time"
+)
+
+const (
+	ForeignKeyViolation = "foreign_key_violation"
+	UniqueViolation     = "unique_violation"
+	CheckViolation      = "check_violation"
+	DeadlockDetected    = "deadlock_detected"
+
+	balanceConstraint = "balance_within_overdraft_limit"
+)
+
+// Domain errors returned by Store, so callers don't have to know about the database driver
+var (
+	ErrRecordNotFound      = errors.New("record not found")
+	ErrUniqueViolation     = errors.New("record already exists")
+	ErrForeignKeyViolation = errors.New("referenced record doesn't exist")
+	// ErrInsufficientFunds is returned when a transfer would take the balance below the account overdraft limit
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrCurrencyMismatch  = errors.New("currency mismatch")
+	ErrRateNotFound      = errors.New("exchange rate not found")
+	// ErrAmountTooSmall is returned when the converted amount of a transfer rounds down to zero
+	ErrAmountTooSmall = errors.New("converted amount is too small")
+	// ErrIdempotencyKeyExists is returned when a concurrent request has already stored a result under the same key
+	ErrIdempotencyKeyExists = errors.New("idempotency key already used")
+	// ErrAccountNotActive is returned when money is moved from or to a frozen or closed account
+	ErrAccountNotActive = errors.New("account is not active")
+	// ErrInvalidStatusTransition is returned when the account can't go from its status to the requested one
+	ErrInvalidStatusTransition = errors.New("invalid account status transition")
+	// ErrBalanceNotZero is returned when closing an account which still holds or owes money
+	ErrBalanceNotZero = errors.New("account balance is not zero")
+	// ErrReversalExceedsTransfer is returned when a reversal would refund more than what's left of the transfer
+	ErrReversalExceedsTransfer = errors.New("reversal exceeds the transfer amount")
+	// ErrReversalOfReversal is returned when reversing a transfer which is itself a reversal
+	ErrReversalOfReversal = errors.New("a reversal can't be reversed")
+	// ErrInvalidTransferStatus is returned when capturing or voiding a transfer which isn't pending,
+	// or reversing one which isn't posted
+	ErrInvalidTransferStatus = errors.New("invalid transfer status")
+	// ErrLimitExceeded is matched by LimitExceededError, which tells which transfer limit would be exceeded
+	ErrLimitExceeded = errors.New("transfer limit exceeded")
+)
+
+// storeError reports the domain error message only, but keeps the driver error in the chain
+type storeError struct {
+	domain error
+	cause  error
+}
+
+func (e *storeError) Error() string {
+	return e.domain.Error()
+}
+
+func (e *storeError) Unwrap() []error {
+	return []error{e.domain, e.cause}
+}
+
+// wrapError translates driver errors into domain errors. Unknown errors are returned as is.
+func wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return &storeError{domain: ErrRecordNotFound, cause: err}
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Name() {
+		case UniqueViolation:
+			return &storeError{domain: ErrUniqueViolation, cause: err}
+		case ForeignKeyViolation:
+			return &storeError{domain: ErrForeignKeyViolation, cause: err}
+		case CheckViolation:
+			if pqErr.Constraint == balanceConstraint {
+				return &storeError{domain: ErrInsufficientFunds, cause: err}
+			}
+		}
+	}
+
+	return err
+}
+
+// isDeadlock tells whether the database aborted the transaction to break a deadlock, it's safe to run again then
+func isDeadlock(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code.Name() == DeadlockDetected
+}
+
+func wrap[T any](result T, err error) (T, error) {
+	return result, wrapError(err)
+}
+
+// SQLStore shadows every generated query, so errors never leave the store untranslated
+
+func (s *SQLStore) AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error) {
+	return wrap(s.Queries.AddAccountBalance(ctx, arg))
+}
+
+func (s *SQLStore) AddAccountHold(ctx context.Context, arg AddAccountHoldParams) (Account, error) {
+	return wrap(s.Queries.AddAccountHold(ctx, arg))
+}
+
+func (s *SQLStore) BlockSession(ctx context.Context, id uuid.UUID) (Session, error) {
+	return wrap(s.Queries.BlockSession(ctx, id))
+}
+
+func (s *SQLStore) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	return wrap(s.Queries.CreateAccount(ctx, arg))
+}
+
+func (s *SQLStore) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
+	return wrap(s.Queries.CreateEntry(ctx, arg))
+}
+
+func (s *SQLStore) CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error) {
+	return wrap(s.Queries.CreateIdempotencyKey(ctx, arg))
+}
+
+func (s *SQLStore) CreatePendingTransfer(ctx context.Context, arg CreatePendingTransferParams) (Transfer, error) {
+	return wrap(s.Queries.CreatePendingTransfer(ctx, arg))
+}
+
+func (s *SQLStore) CreateRate(ctx context.Context, arg CreateRateParams) (Rate, error) {
+	return wrap(s.Queries.CreateRate(ctx, arg))
+}
+
+func (s *SQLStore) CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfer, error) {
+	return wrap(s.Queries.CreateScheduledTransfer(ctx, arg))
+}
+
+func (s *SQLStore) CreateScheduledTransferRun(ctx context.Context, arg CreateScheduledTransferRunParams) (ScheduledTransferRun, error) {
+	return wrap(s.Queries.CreateScheduledTransferRun(ctx, arg))
+}
+
+func (s *SQLStore) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
+	return wrap(s.Queries.CreateSession(ctx, arg))
+}
+
+func (s *SQLStore) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
+	return wrap(s.Queries.CreateTransfer(ctx, arg))
+}
+
+func (s *SQLStore) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	return wrap(s.Queries.CreateUser(ctx, arg))
+}
+
+func (s *SQLStore) DeleteAccount(ctx context.Context, id int64) error {
+	return wrapError(s.Queries.DeleteAccount(ctx, id))
+}
+
+func (s *SQLStore) DeleteEntry(ctx context.Context, accountID int64) error {
+	return wrapError(s.Queries.DeleteEntry(ctx, accountID))
+}
+
+func (s *SQLStore) DeleteExpiredIdempotencyKeys(ctx context.Context, expiresAt time.Time) (int64, error) {
+	return wrap(s.Queries.DeleteExpiredIdempotencyKeys(ctx, expiresAt))
+}
+
+func (s *SQLStore) DeleteExpiredRevokedTokens(ctx context.Context, expiresAt time.Time) (int64, error) {
+	return wrap(s.Queries.DeleteExpiredRevokedTokens(ctx, expiresAt))
+}
+
+func (s *SQLStore) DeleteExpiredSessions(ctx context.Context, expiresAt time.Time) (int64, error) {
+	return wrap(s.Queries.DeleteExpiredSessions(ctx, expiresAt))
+}
+
+func (s *SQLStore) DeleteScheduledTransfer(ctx context.Context, id int64) error {
+	return wrapError(s.Queries.DeleteScheduledTransfer(ctx, id))
+}
+
+func (s *SQLStore) DeleteUserTransferLimit(ctx context.Context, arg DeleteUserTransferLimitParams) error {
+	return wrapError(s.Queries.DeleteUserTransferLimit(ctx, arg))
+}
+
+func (s *SQLStore) GetAccount(ctx context.Context, id int64) (Account, error) {
+	return wrap(s.Queries.GetAccount(ctx, id))
+}
+
+func (s *SQLStore) GetAccountBalanceAt(ctx context.Context, arg GetAccountBalanceAtParams) (int64, error) {
+	return wrap(s.Queries.GetAccountBalanceAt(ctx, arg))
+}
+
+func (s *SQLStore) GetAccountForUpdate(ctx context.Context, id int64) (Account, error) {
+	return wrap(s.Queries.GetAccountForUpdate(ctx, id))
+}
+
+func (s *SQLStore) GetCurrency(ctx context.Context, code string) (Currency, error) {
+	return wrap(s.Queries.GetCurrency(ctx, code))
+}
+
+func (s *SQLStore) GetDueScheduledTransfer(ctx context.Context, now time.Time) (ScheduledTransfer, error) {
+	return wrap(s.Queries.GetDueScheduledTransfer(ctx, now))
+}
+
+func (s *SQLStore) GetEffectiveTransferLimit(ctx context.Context, arg GetEffectiveTransferLimitParams) (GetEffectiveTransferLimitRow, error) {
+	return wrap(s.Queries.GetEffectiveTransferLimit(ctx, arg))
+}
+
+func (s *SQLStore) GetEntry(ctx context.Context, id int64) (Entry, error) {
+	return wrap(s.Queries.GetEntry(ctx, id))
+}
+
+func (s *SQLStore) GetEntryByAccountID(ctx context.Context, accountID int64) (Entry, error) {
+	return wrap(s.Queries.GetEntryByAccountID(ctx, accountID))
+}
+
+func (s *SQLStore) GetExpiredPendingTransfer(ctx context.Context, now time.Time) (Transfer, error) {
+	return wrap(s.Queries.GetExpiredPendingTransfer(ctx, now))
+}
+
+func (s *SQLStore) GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error) {
+	return wrap(s.Queries.GetIdempotencyKey(ctx, arg))
+}
+
+func (s *SQLStore) GetOutgoingTotals(ctx context.Context, arg GetOutgoingTotalsParams) (GetOutgoingTotalsRow, error) {
+	return wrap(s.Queries.GetOutgoingTotals(ctx, arg))
+}
+
+func (s *SQLStore) GetRate(ctx context.Context, arg GetRateParams) (Rate, error) {
+	return wrap(s.Queries.GetRate(ctx, arg))
+}
+
+func (s *SQLStore) GetReversedAmounts(ctx context.Context, reversalOf *int64) (GetReversedAmountsRow, error) {
+	return wrap(s.Queries.GetReversedAmounts(ctx, reversalOf))
+}
+
+func (s *SQLStore) GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error) {
+	return wrap(s.Queries.GetScheduledTransfer(ctx, id))
+}
+
+func (s *SQLStore) GetSession(ctx context.Context, id uuid.UUID) (Session, error) {
+	return wrap(s.Queries.GetSession(ctx, id))
+}
+
+func (s *SQLStore) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
+	return wrap(s.Queries.GetTransfer(ctx, id))
+}
+
+func (s *SQLStore) GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error) {
+	return wrap(s.Queries.GetTransferForUpdate(ctx, id))
+}
+
+func (s *SQLStore) GetUser(ctx context.Context, username string) (User, error) {
+	return wrap(s.Queries.GetUser(ctx, username))
+}
+
+func (s *SQLStore) IsSessionBlocked(ctx context.Context, id uuid.UUID) (bool, error) {
+	return wrap(s.Queries.IsSessionBlocked(ctx, id))
+}
+
+func (s *SQLStore) IsTokenRevoked(ctx context.Context, id uuid.UUID) (bool, error) {
+	return wrap(s.Queries.IsTokenRevoked(ctx, id))
+}
+
+func (s *SQLStore) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
+	return wrap(s.Queries.ListAccounts(ctx, arg))
+}
+
+func (s *SQLStore) ListBalanceMismatches(ctx context.Context) ([]ListBalanceMismatchesRow, error) {
+	return wrap(s.Queries.ListBalanceMismatches(ctx))
+}
+
+func (s *SQLStore) ListCurrencies(ctx context.Context) ([]Currency, error) {
+	return wrap(s.Queries.ListCurrencies(ctx))
+}
+
+func (s *SQLStore) ListEffectiveTransferLimits(ctx context.Context, username string) ([]ListEffectiveTransferLimitsRow, error) {
+	return wrap(s.Queries.ListEffectiveTransferLimits(ctx, username))
+}
+
+func (s *SQLStore) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
+	return wrap(s.Queries.ListEntries(ctx, arg))
+}
+
+func (s *SQLStore) ListEntriesByAccountID(ctx context.Context, arg ListEntriesByAccountIDParams) ([]ListEntriesByAccountIDRow, error) {
+	return wrap(s.Queries.ListEntriesByAccountID(ctx, arg))
+}
+
+func (s *SQLStore) ListScheduledTransferRuns(ctx context.Context, arg ListScheduledTransferRunsParams) ([]ScheduledTransferRun, error) {
+	return wrap(s.Queries.ListScheduledTransferRuns(ctx, arg))
+}
+
+func (s *SQLStore) ListScheduledTransfers(ctx context.Context, arg ListScheduledTransfersParams) ([]ScheduledTransfer, error) {
+	return wrap(s.Queries.ListScheduledTransfers(ctx, arg))
+}
+
+func (s *SQLStore) ListStatementLines(ctx context.Context, arg ListStatementLinesParams) ([]ListStatementLinesRow, error) {
+	return wrap(s.Queries.ListStatementLines(ctx, arg))
+}
+
+func (s *SQLStore) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
+	return wrap(s.Queries.ListTransfers(ctx, arg))
+}
+
+func (s *SQLStore) ListUnbalancedTransfers(ctx context.Context) ([]ListUnbalancedTransfersRow, error) {
+	return wrap(s.Queries.ListUnbalancedTransfers(ctx))
+}
+
+func (s *SQLStore) RescheduleScheduledTransfer(ctx context.Context, arg RescheduleScheduledTransferParams) (ScheduledTransfer, error) {
+	return wrap(s.Queries.RescheduleScheduledTransfer(ctx, arg))
+}
+
+func (s *SQLStore) RevokeToken(ctx context.Context, arg RevokeTokenParams) error {
+	return wrapError(s.Queries.RevokeToken(ctx, arg))
+}
+
+func (s *SQLStore) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
+	return wrap(s.Queries.UpdateAccount(ctx, arg))
+}
+
+func (s *SQLStore) UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error) {
+	return wrap(s.Queries.UpdateAccountOverdraftLimit(ctx, arg))
+}
+
+func (s *SQLStore) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	return wrap(s.Queries.UpdateAccountStatus(ctx, arg))
+}
+
+func (s *SQLStore) UpdateEntry(ctx context.Context, arg UpdateEntryParams) (Entry, error) {
+	return wrap(s.Queries.UpdateEntry(ctx, arg))
+}
+
+func (s *SQLStore) UpdateScheduledTransfer(ctx context.Context, arg UpdateScheduledTransferParams) (ScheduledTransfer, error) {
+	return wrap(s.Queries.UpdateScheduledTransfer(ctx, arg))
+}
+
+func (s *SQLStore) UpdateTransferStatus(ctx context.Context, arg UpdateTransferStatusParams) (Transfer, error) {
+	return wrap(s.Queries.UpdateTransferStatus(ctx, arg))
+}
+
+func (s *SQLStore) UpsertTransferLimit(ctx context.Context, arg UpsertTransferLimitParams) (TransferLimit, error) {
+	return wrap(s.Queries.UpsertTransferLimit(ctx, arg))
+}
+
+func (s *SQLStore) UpsertUserTransferLimit(ctx context.Context, arg UpsertUserTransferLimitParams) (UserTransferLimit, error) {
+	return wrap(s.Queries.UpsertUserTransferLimit(ctx, arg))
+}
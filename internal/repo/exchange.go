@@ -0,0 +1,104 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// sameCurrencyRate is recorded on transfers which don't need an exchange
+const sameCurrencyRate = "1"
+
+// ExchangeTransferTx works as TransferTx, but accounts may be in different currencies.
+// The source account is debited with the amount in its currency and the destination account
+// is credited with the amount converted by the latest rate of the pair.
+func (s *SQLStore) ExchangeTransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
+	return s.transferTx(ctx, arg, true)
+}
+
+// LoadRatesTx stores a batch of rates within a single database transaction,
+// so transfers never see a partially loaded batch
+func (s *SQLStore) LoadRatesTx(ctx context.Context, arg []CreateRateParams) ([]Rate, error) {
+	rates := make([]Rate, 0, len(arg))
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		rates = rates[:0]
+		for _, params := range arg {
+			rate, err := q.CreateRate(ctx, params)
+			if err != nil {
+				return err
+			}
+			rates = append(rates, rate)
+		}
+		return nil
+	})
+
+	return rates, wrapError(err)
+}
+
+func exchangeAmount(ctx context.Context, q *Queries, from, to string, amount int64) (rate string, converted int64, err error) {
+	r, err := q.GetRate(ctx, GetRateParams{
+		BaseCurrency:  from,
+		QuoteCurrency: to,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", 0, fmt.Errorf("%w: %s/%s", ErrRateNotFound, from, to)
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	// rates are quoted for major units, while amounts are kept in minor units of each currency
+	fromCurrency, err := q.GetCurrency(ctx, from)
+	if err != nil {
+		return "", 0, err
+	}
+	toCurrency, err := q.GetCurrency(ctx, to)
+	if err != nil {
+		return "", 0, err
+	}
+
+	converted, err = convertAmount(amount, r.Rate, toCurrency.Exponent-fromCurrency.Exponent)
+	return r.Rate, converted, err
+}
+
+// convertAmount multiplies the amount by the decimal rate, shifts it by the difference of minor unit exponents
+// and rounds the result half up. Amounts of transfers are always positive.
+func convertAmount(amount int64, rate string, exponentShift int32) (int64, error) {
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok {
+		return 0, fmt.Errorf("invalid rate %q", rate)
+	}
+	r.Mul(r, new(big.Rat).SetInt64(amount))
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(exponentShift))), nil)
+	if exponentShift >= 0 {
+		r.Mul(r, new(big.Rat).SetInt(scale))
+	} else {
+		r.Quo(r, new(big.Rat).SetInt(scale))
+	}
+
+	// floor((2 * num + den) / (2 * den)) rounds half up
+	num := new(big.Int).Lsh(r.Num(), 1)
+	num.Add(num, r.Denom())
+	den := new(big.Int).Lsh(r.Denom(), 1)
+	converted := num.Quo(num, den)
+
+	if !converted.IsInt64() {
+		return 0, fmt.Errorf("converted amount of %d by rate %s overflows", amount, rate)
+	}
+	if converted.Sign() <= 0 {
+		return 0, fmt.Errorf("%w: %d by rate %s", ErrAmountTooSmall, amount, rate)
+	}
+
+	return converted.Int64(), nil
+}
+
+func abs(x int32) int32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
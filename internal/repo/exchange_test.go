@@ -0,0 +1,100 @@
+package repo
+
+import (
+	"context"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestConvertAmount(t *testing.T) {
+	testCases := []struct {
+		name      string
+		amount    int64
+		rate      string
+		converted int64
+		shift     int32
+		err       error
+	}{
+		{name: "SameCurrency", amount: 100, rate: sameCurrencyRate, converted: 100},
+		{name: "RoundDown", amount: 101, rate: "0.92", converted: 93},
+		{name: "RoundHalfUp", amount: 25, rate: "0.1", converted: 3},
+		{name: "LargeRate", amount: 100, rate: "41.2345678900", converted: 4123},
+		{name: "MoreMinorUnits", amount: 150, rate: "2", shift: 1, converted: 3000},
+		{name: "FewerMinorUnits", amount: 150, rate: "2", shift: -2, converted: 3},
+		{name: "TooSmall", amount: 1, rate: "0.0001", err: ErrAmountTooSmall},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			converted, err := convertAmount(tc.amount, tc.rate, tc.shift)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.converted, converted)
+		})
+	}
+}
+
+func TestStore_ExchangeTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
+
+	_, err := testDB.Exec("update accounts set currency = $1 where id = $2", util.EUR, account2.ID)
+	require.NoError(t, err)
+
+	_, err = store.LoadRatesTx(context.Background(), []CreateRateParams{
+		{
+			BaseCurrency:  util.USD,
+			QuoteCurrency: util.EUR,
+			Rate:          "0.5",
+			ValidFrom:     time.Now().Add(-time.Second),
+		},
+	})
+	require.NoError(t, err)
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+	}
+
+	// the plain transfer still refuses different currencies
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	result, err := store.ExchangeTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, int64(100), result.Transfer.Amount)
+	require.Equal(t, int64(50), result.Transfer.ToAmount)
+	require.Equal(t, "0.5000000000", result.Transfer.Rate)
+	require.Equal(t, int64(-100), result.FromEntry.Amount)
+	require.Equal(t, int64(50), result.ToEntry.Amount)
+	require.Equal(t, account1.Balance-100, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+50, result.ToAccount.Balance)
+}
+
+func TestStore_ExchangeTransferTxRateNotFound(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
+
+	_, err := testDB.Exec("update accounts set currency = $1 where id = $2", util.UAH, account2.ID)
+	require.NoError(t, err)
+
+	_, err = store.ExchangeTransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+	})
+	require.ErrorIs(t, err, ErrRateNotFound)
+}
@@ -0,0 +1,30 @@
+package repo
+
+import (
+	"context"
+)
+
+// SchemaVersion is the version of the latest migration the code relies on, bump it with every new migration
+const SchemaVersion = 25
+
+// schema_migrations is maintained by migrate, it isn't a part of the sqlc schema
+const getMigrationStatus = `select version, dirty from schema_migrations limit 1`
+
+// MigrationStatus is the schema version recorded by migrate.
+// A dirty version means a migration failed halfway and needs a manual fix.
+type MigrationStatus struct {
+	Version int64 `json:"version"`
+	Dirty   bool  `json:"dirty"`
+}
+
+// Ping checks the database is reachable
+func (s *SQLStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// GetMigrationStatus returns ErrRecordNotFound when no migration has been applied
+func (s *SQLStore) GetMigrationStatus(ctx context.Context) (MigrationStatus, error) {
+	var status MigrationStatus
+	err := s.db.QueryRowContext(ctx, getMigrationStatus).Scan(&status.Version, &status.Dirty)
+	return status, wrapError(err)
+}
@@ -0,0 +1,40 @@
+package repo
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSchemaVersion(t *testing.T) {
+	files, err := os.ReadDir("../../migrations")
+	require.NoError(t, err)
+
+	var latest int64
+	for _, file := range files {
+		prefix, _, found := strings.Cut(file.Name(), "_")
+		require.True(t, found, file.Name())
+
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		require.NoError(t, err)
+		if version > latest {
+			latest = version
+		}
+	}
+
+	require.Equal(t, latest, int64(SchemaVersion))
+}
+
+func TestStore_GetMigrationStatus(t *testing.T) {
+	store := NewStore(testDB)
+
+	require.NoError(t, store.Ping(context.Background()))
+
+	status, err := store.GetMigrationStatus(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(SchemaVersion), status.Version)
+	require.False(t, status.Dirty)
+}
@@ -0,0 +1,48 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// LedgerReport lists the discrepancies between the balances, entries and transfers of the ledger.
+// The ledger is consistent when both lists are empty.
+type LedgerReport struct {
+	CheckedAt time.Time `json:"checked_at"`
+	// accounts whose balance isn't the sum of their entries
+	BalanceMismatches []ListBalanceMismatchesRow `json:"balance_mismatches"`
+	// transfers without exactly one debit and one credit entry matching their amounts
+	UnbalancedTransfers []ListUnbalancedTransfersRow `json:"unbalanced_transfers"`
+}
+
+// Consistent tells whether no discrepancy was found
+func (r LedgerReport) Consistent() bool {
+	return len(r.BalanceMismatches) == 0 && len(r.UnbalancedTransfers) == 0
+}
+
+// VerifyLedger checks the whole ledger. Both checks read the same snapshot,
+// so transfers made in the meantime don't show up as discrepancies.
+func (s *SQLStore) VerifyLedger(ctx context.Context) (LedgerReport, error) {
+	report := LedgerReport{CheckedAt: time.Now().UTC()}
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return report, err
+	}
+	// nothing is written, the transaction only holds the snapshot
+	defer tx.Rollback()
+
+	q := New(tx)
+	report.BalanceMismatches, err = q.ListBalanceMismatches(ctx)
+	if err != nil {
+		return report, wrapError(err)
+	}
+
+	report.UnbalancedTransfers, err = q.ListUnbalancedTransfers(ctx)
+	if err != nil {
+		return report, wrapError(err)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,52 @@
+package repo
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestStore_VerifyLedger(t *testing.T) {
+	store := NewStore(testDB)
+
+	// funded accounts have a balance without entries, so they don't match their entries
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
+
+	balanced, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+
+	// a transfer without entries
+	unbalanced := createRandomTransfer(t)
+
+	report, err := store.VerifyLedger(context.Background())
+	require.NoError(t, err)
+	require.False(t, report.Consistent())
+	require.NotZero(t, report.CheckedAt)
+
+	require.Contains(t, report.BalanceMismatches, ListBalanceMismatchesRow{
+		AccountID:  account1.ID,
+		Balance:    900,
+		EntriesSum: -100,
+	})
+	require.Contains(t, report.BalanceMismatches, ListBalanceMismatchesRow{
+		AccountID:  account2.ID,
+		Balance:    1100,
+		EntriesSum: 100,
+	})
+
+	require.Contains(t, report.UnbalancedTransfers, ListUnbalancedTransfersRow{
+		TransferID:    unbalanced.ID,
+		FromAccountID: unbalanced.FromAccountID,
+		ToAccountID:   unbalanced.ToAccountID,
+		Amount:        unbalanced.Amount,
+		ToAmount:      unbalanced.ToAmount,
+	})
+	for _, transfer := range report.UnbalancedTransfers {
+		require.NotEqual(t, balanced.Transfer.ID, transfer.TransferID)
+	}
+}
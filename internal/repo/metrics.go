@@ -0,0 +1,43 @@
+package repo
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Results of database transactions
+const (
+	txCommitted  = "commit"
+	txRolledBack = "rollback"
+)
+
+var (
+	dbTransactions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bank_db_transactions_total",
+		Help: "Database transactions of the store by how they ended.",
+	}, []string{"result"})
+	deadlockRetries = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "bank_db_deadlock_retries_total",
+		Help: "Transactions retried after the database detected a deadlock.",
+	})
+	transferTxDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "bank_transfer_tx_duration_seconds",
+		Help:    "Duration of TransferTx and ExchangeTransferTx, deadlock retries included.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"result"})
+	transfersPosted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bank_transfers_total",
+		Help: "Posted transfers by the currency of the source account.",
+	}, []string{"currency"})
+	transferVolume = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bank_transfer_volume_total",
+		Help: "Money moved by posted transfers in minor units of the source account currency.",
+	}, []string{"currency"})
+)
+
+// observePosted counts a posted transfer once its transaction has committed,
+// the amount is in the currency of the source account
+func observePosted(currency string, amount int64) {
+	transfersPosted.WithLabelValues(currency).Inc()
+	transferVolume.WithLabelValues(currency).Add(float64(amount))
+}
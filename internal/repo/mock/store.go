@@ -7,8 +7,10 @@ package mock_repo
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
 	repo "github.com/max-rodziyevsky/go-simple-bank/internal/repo"
 )
 
@@ -50,6 +52,96 @@ func (mr *MockStoreMockRecorder) AddAccountBalance(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountBalance", reflect.TypeOf((*MockStore)(nil).AddAccountBalance), arg0, arg1)
 }
 
+// AddAccountHold mocks base method.
+func (m *MockStore) AddAccountHold(arg0 context.Context, arg1 repo.AddAccountHoldParams) (repo.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAccountHold", arg0, arg1)
+	ret0, _ := ret[0].(repo.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAccountHold indicates an expected call of AddAccountHold.
+func (mr *MockStoreMockRecorder) AddAccountHold(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountHold", reflect.TypeOf((*MockStore)(nil).AddAccountHold), arg0, arg1)
+}
+
+// AdjustBalanceTx mocks base method.
+func (m *MockStore) AdjustBalanceTx(arg0 context.Context, arg1 repo.AdjustBalanceTxParams) (repo.BalanceChangeTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdjustBalanceTx", arg0, arg1)
+	ret0, _ := ret[0].(repo.BalanceChangeTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdjustBalanceTx indicates an expected call of AdjustBalanceTx.
+func (mr *MockStoreMockRecorder) AdjustBalanceTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdjustBalanceTx", reflect.TypeOf((*MockStore)(nil).AdjustBalanceTx), arg0, arg1)
+}
+
+// AuthorizeTransferTx mocks base method.
+func (m *MockStore) AuthorizeTransferTx(arg0 context.Context, arg1 repo.AuthorizeTransferTxParams) (repo.PendingTransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeTransferTx", arg0, arg1)
+	ret0, _ := ret[0].(repo.PendingTransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthorizeTransferTx indicates an expected call of AuthorizeTransferTx.
+func (mr *MockStoreMockRecorder) AuthorizeTransferTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeTransferTx", reflect.TypeOf((*MockStore)(nil).AuthorizeTransferTx), arg0, arg1)
+}
+
+// BlockSession mocks base method.
+func (m *MockStore) BlockSession(arg0 context.Context, arg1 uuid.UUID) (repo.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockSession", arg0, arg1)
+	ret0, _ := ret[0].(repo.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockSession indicates an expected call of BlockSession.
+func (mr *MockStoreMockRecorder) BlockSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockSession", reflect.TypeOf((*MockStore)(nil).BlockSession), arg0, arg1)
+}
+
+// CaptureTransferTx mocks base method.
+func (m *MockStore) CaptureTransferTx(arg0 context.Context, arg1 int64) (repo.TransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CaptureTransferTx", arg0, arg1)
+	ret0, _ := ret[0].(repo.TransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CaptureTransferTx indicates an expected call of CaptureTransferTx.
+func (mr *MockStoreMockRecorder) CaptureTransferTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CaptureTransferTx", reflect.TypeOf((*MockStore)(nil).CaptureTransferTx), arg0, arg1)
+}
+
+// ChangeAccountStatusTx mocks base method.
+func (m *MockStore) ChangeAccountStatusTx(arg0 context.Context, arg1 repo.ChangeAccountStatusTxParams) (repo.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeAccountStatusTx", arg0, arg1)
+	ret0, _ := ret[0].(repo.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeAccountStatusTx indicates an expected call of ChangeAccountStatusTx.
+func (mr *MockStoreMockRecorder) ChangeAccountStatusTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeAccountStatusTx", reflect.TypeOf((*MockStore)(nil).ChangeAccountStatusTx), arg0, arg1)
+}
+
 // CreateAccount mocks base method.
 func (m *MockStore) CreateAccount(arg0 context.Context, arg1 repo.CreateAccountParams) (repo.Account, error) {
 	m.ctrl.T.Helper()
@@ -80,6 +172,96 @@ func (mr *MockStoreMockRecorder) CreateEntry(arg0, arg1 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEntry", reflect.TypeOf((*MockStore)(nil).CreateEntry), arg0, arg1)
 }
 
+// CreateIdempotencyKey mocks base method.
+func (m *MockStore) CreateIdempotencyKey(arg0 context.Context, arg1 repo.CreateIdempotencyKeyParams) (repo.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIdempotencyKey", arg0, arg1)
+	ret0, _ := ret[0].(repo.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIdempotencyKey indicates an expected call of CreateIdempotencyKey.
+func (mr *MockStoreMockRecorder) CreateIdempotencyKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIdempotencyKey", reflect.TypeOf((*MockStore)(nil).CreateIdempotencyKey), arg0, arg1)
+}
+
+// CreatePendingTransfer mocks base method.
+func (m *MockStore) CreatePendingTransfer(arg0 context.Context, arg1 repo.CreatePendingTransferParams) (repo.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePendingTransfer", arg0, arg1)
+	ret0, _ := ret[0].(repo.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePendingTransfer indicates an expected call of CreatePendingTransfer.
+func (mr *MockStoreMockRecorder) CreatePendingTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePendingTransfer", reflect.TypeOf((*MockStore)(nil).CreatePendingTransfer), arg0, arg1)
+}
+
+// CreateRate mocks base method.
+func (m *MockStore) CreateRate(arg0 context.Context, arg1 repo.CreateRateParams) (repo.Rate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRate", arg0, arg1)
+	ret0, _ := ret[0].(repo.Rate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRate indicates an expected call of CreateRate.
+func (mr *MockStoreMockRecorder) CreateRate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRate", reflect.TypeOf((*MockStore)(nil).CreateRate), arg0, arg1)
+}
+
+// CreateScheduledTransfer mocks base method.
+func (m *MockStore) CreateScheduledTransfer(arg0 context.Context, arg1 repo.CreateScheduledTransferParams) (repo.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateScheduledTransfer", arg0, arg1)
+	ret0, _ := ret[0].(repo.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateScheduledTransfer indicates an expected call of CreateScheduledTransfer.
+func (mr *MockStoreMockRecorder) CreateScheduledTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateScheduledTransfer", reflect.TypeOf((*MockStore)(nil).CreateScheduledTransfer), arg0, arg1)
+}
+
+// CreateScheduledTransferRun mocks base method.
+func (m *MockStore) CreateScheduledTransferRun(arg0 context.Context, arg1 repo.CreateScheduledTransferRunParams) (repo.ScheduledTransferRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateScheduledTransferRun", arg0, arg1)
+	ret0, _ := ret[0].(repo.ScheduledTransferRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateScheduledTransferRun indicates an expected call of CreateScheduledTransferRun.
+func (mr *MockStoreMockRecorder) CreateScheduledTransferRun(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateScheduledTransferRun", reflect.TypeOf((*MockStore)(nil).CreateScheduledTransferRun), arg0, arg1)
+}
+
+// CreateSession mocks base method.
+func (m *MockStore) CreateSession(arg0 context.Context, arg1 repo.CreateSessionParams) (repo.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1)
+	ret0, _ := ret[0].(repo.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockStoreMockRecorder) CreateSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockStore)(nil).CreateSession), arg0, arg1)
+}
+
 // CreateTransfer mocks base method.
 func (m *MockStore) CreateTransfer(arg0 context.Context, arg1 repo.CreateTransferParams) (repo.Transfer, error) {
 	m.ctrl.T.Helper()
@@ -138,6 +320,109 @@ func (mr *MockStoreMockRecorder) DeleteEntry(arg0, arg1 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEntry", reflect.TypeOf((*MockStore)(nil).DeleteEntry), arg0, arg1)
 }
 
+// DeleteExpiredIdempotencyKeys mocks base method.
+func (m *MockStore) DeleteExpiredIdempotencyKeys(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredIdempotencyKeys", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredIdempotencyKeys indicates an expected call of DeleteExpiredIdempotencyKeys.
+func (mr *MockStoreMockRecorder) DeleteExpiredIdempotencyKeys(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredIdempotencyKeys", reflect.TypeOf((*MockStore)(nil).DeleteExpiredIdempotencyKeys), arg0, arg1)
+}
+
+// DeleteExpiredRevokedTokens mocks base method.
+func (m *MockStore) DeleteExpiredRevokedTokens(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredRevokedTokens", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredRevokedTokens indicates an expected call of DeleteExpiredRevokedTokens.
+func (mr *MockStoreMockRecorder) DeleteExpiredRevokedTokens(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredRevokedTokens", reflect.TypeOf((*MockStore)(nil).DeleteExpiredRevokedTokens), arg0, arg1)
+}
+
+// DeleteExpiredSessions mocks base method.
+func (m *MockStore) DeleteExpiredSessions(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredSessions", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredSessions indicates an expected call of DeleteExpiredSessions.
+func (mr *MockStoreMockRecorder) DeleteExpiredSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredSessions", reflect.TypeOf((*MockStore)(nil).DeleteExpiredSessions), arg0, arg1)
+}
+
+// DeleteScheduledTransfer mocks base method.
+func (m *MockStore) DeleteScheduledTransfer(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteScheduledTransfer", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteScheduledTransfer indicates an expected call of DeleteScheduledTransfer.
+func (mr *MockStoreMockRecorder) DeleteScheduledTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteScheduledTransfer", reflect.TypeOf((*MockStore)(nil).DeleteScheduledTransfer), arg0, arg1)
+}
+
+// DeleteUserTransferLimit mocks base method.
+func (m *MockStore) DeleteUserTransferLimit(arg0 context.Context, arg1 repo.DeleteUserTransferLimitParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserTransferLimit", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserTransferLimit indicates an expected call of DeleteUserTransferLimit.
+func (mr *MockStoreMockRecorder) DeleteUserTransferLimit(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserTransferLimit", reflect.TypeOf((*MockStore)(nil).DeleteUserTransferLimit), arg0, arg1)
+}
+
+// DepositTx mocks base method.
+func (m *MockStore) DepositTx(arg0 context.Context, arg1 repo.BalanceChangeTxParams) (repo.BalanceChangeTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DepositTx", arg0, arg1)
+	ret0, _ := ret[0].(repo.BalanceChangeTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DepositTx indicates an expected call of DepositTx.
+func (mr *MockStoreMockRecorder) DepositTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DepositTx", reflect.TypeOf((*MockStore)(nil).DepositTx), arg0, arg1)
+}
+
+// ExchangeTransferTx mocks base method.
+func (m *MockStore) ExchangeTransferTx(arg0 context.Context, arg1 repo.TransferTxParams) (repo.TransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExchangeTransferTx", arg0, arg1)
+	ret0, _ := ret[0].(repo.TransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExchangeTransferTx indicates an expected call of ExchangeTransferTx.
+func (mr *MockStoreMockRecorder) ExchangeTransferTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExchangeTransferTx", reflect.TypeOf((*MockStore)(nil).ExchangeTransferTx), arg0, arg1)
+}
+
 // GetAccount mocks base method.
 func (m *MockStore) GetAccount(arg0 context.Context, arg1 int64) (repo.Account, error) {
 	m.ctrl.T.Helper()
@@ -153,6 +438,21 @@ func (mr *MockStoreMockRecorder) GetAccount(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockStore)(nil).GetAccount), arg0, arg1)
 }
 
+// GetAccountBalanceAt mocks base method.
+func (m *MockStore) GetAccountBalanceAt(arg0 context.Context, arg1 repo.GetAccountBalanceAtParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountBalanceAt", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountBalanceAt indicates an expected call of GetAccountBalanceAt.
+func (mr *MockStoreMockRecorder) GetAccountBalanceAt(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountBalanceAt", reflect.TypeOf((*MockStore)(nil).GetAccountBalanceAt), arg0, arg1)
+}
+
 // GetAccountForUpdate mocks base method.
 func (m *MockStore) GetAccountForUpdate(arg0 context.Context, arg1 int64) (repo.Account, error) {
 	m.ctrl.T.Helper()
@@ -168,6 +468,51 @@ func (mr *MockStoreMockRecorder) GetAccountForUpdate(arg0, arg1 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountForUpdate", reflect.TypeOf((*MockStore)(nil).GetAccountForUpdate), arg0, arg1)
 }
 
+// GetCurrency mocks base method.
+func (m *MockStore) GetCurrency(arg0 context.Context, arg1 string) (repo.Currency, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCurrency", arg0, arg1)
+	ret0, _ := ret[0].(repo.Currency)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCurrency indicates an expected call of GetCurrency.
+func (mr *MockStoreMockRecorder) GetCurrency(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrency", reflect.TypeOf((*MockStore)(nil).GetCurrency), arg0, arg1)
+}
+
+// GetDueScheduledTransfer mocks base method.
+func (m *MockStore) GetDueScheduledTransfer(arg0 context.Context, arg1 time.Time) (repo.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueScheduledTransfer", arg0, arg1)
+	ret0, _ := ret[0].(repo.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueScheduledTransfer indicates an expected call of GetDueScheduledTransfer.
+func (mr *MockStoreMockRecorder) GetDueScheduledTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueScheduledTransfer", reflect.TypeOf((*MockStore)(nil).GetDueScheduledTransfer), arg0, arg1)
+}
+
+// GetEffectiveTransferLimit mocks base method.
+func (m *MockStore) GetEffectiveTransferLimit(arg0 context.Context, arg1 repo.GetEffectiveTransferLimitParams) (repo.GetEffectiveTransferLimitRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEffectiveTransferLimit", arg0, arg1)
+	ret0, _ := ret[0].(repo.GetEffectiveTransferLimitRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEffectiveTransferLimit indicates an expected call of GetEffectiveTransferLimit.
+func (mr *MockStoreMockRecorder) GetEffectiveTransferLimit(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEffectiveTransferLimit", reflect.TypeOf((*MockStore)(nil).GetEffectiveTransferLimit), arg0, arg1)
+}
+
 // GetEntry mocks base method.
 func (m *MockStore) GetEntry(arg0 context.Context, arg1 int64) (repo.Entry, error) {
 	m.ctrl.T.Helper()
@@ -198,6 +543,126 @@ func (mr *MockStoreMockRecorder) GetEntryByAccountID(arg0, arg1 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntryByAccountID", reflect.TypeOf((*MockStore)(nil).GetEntryByAccountID), arg0, arg1)
 }
 
+// GetExpiredPendingTransfer mocks base method.
+func (m *MockStore) GetExpiredPendingTransfer(arg0 context.Context, arg1 time.Time) (repo.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpiredPendingTransfer", arg0, arg1)
+	ret0, _ := ret[0].(repo.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpiredPendingTransfer indicates an expected call of GetExpiredPendingTransfer.
+func (mr *MockStoreMockRecorder) GetExpiredPendingTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiredPendingTransfer", reflect.TypeOf((*MockStore)(nil).GetExpiredPendingTransfer), arg0, arg1)
+}
+
+// GetIdempotencyKey mocks base method.
+func (m *MockStore) GetIdempotencyKey(arg0 context.Context, arg1 repo.GetIdempotencyKeyParams) (repo.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdempotencyKey", arg0, arg1)
+	ret0, _ := ret[0].(repo.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdempotencyKey indicates an expected call of GetIdempotencyKey.
+func (mr *MockStoreMockRecorder) GetIdempotencyKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdempotencyKey", reflect.TypeOf((*MockStore)(nil).GetIdempotencyKey), arg0, arg1)
+}
+
+// GetMigrationStatus mocks base method.
+func (m *MockStore) GetMigrationStatus(arg0 context.Context) (repo.MigrationStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMigrationStatus", arg0)
+	ret0, _ := ret[0].(repo.MigrationStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMigrationStatus indicates an expected call of GetMigrationStatus.
+func (mr *MockStoreMockRecorder) GetMigrationStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMigrationStatus", reflect.TypeOf((*MockStore)(nil).GetMigrationStatus), arg0)
+}
+
+// GetOutgoingTotals mocks base method.
+func (m *MockStore) GetOutgoingTotals(arg0 context.Context, arg1 repo.GetOutgoingTotalsParams) (repo.GetOutgoingTotalsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOutgoingTotals", arg0, arg1)
+	ret0, _ := ret[0].(repo.GetOutgoingTotalsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOutgoingTotals indicates an expected call of GetOutgoingTotals.
+func (mr *MockStoreMockRecorder) GetOutgoingTotals(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOutgoingTotals", reflect.TypeOf((*MockStore)(nil).GetOutgoingTotals), arg0, arg1)
+}
+
+// GetRate mocks base method.
+func (m *MockStore) GetRate(arg0 context.Context, arg1 repo.GetRateParams) (repo.Rate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRate", arg0, arg1)
+	ret0, _ := ret[0].(repo.Rate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRate indicates an expected call of GetRate.
+func (mr *MockStoreMockRecorder) GetRate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRate", reflect.TypeOf((*MockStore)(nil).GetRate), arg0, arg1)
+}
+
+// GetReversedAmounts mocks base method.
+func (m *MockStore) GetReversedAmounts(arg0 context.Context, arg1 *int64) (repo.GetReversedAmountsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReversedAmounts", arg0, arg1)
+	ret0, _ := ret[0].(repo.GetReversedAmountsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReversedAmounts indicates an expected call of GetReversedAmounts.
+func (mr *MockStoreMockRecorder) GetReversedAmounts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReversedAmounts", reflect.TypeOf((*MockStore)(nil).GetReversedAmounts), arg0, arg1)
+}
+
+// GetScheduledTransfer mocks base method.
+func (m *MockStore) GetScheduledTransfer(arg0 context.Context, arg1 int64) (repo.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduledTransfer", arg0, arg1)
+	ret0, _ := ret[0].(repo.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduledTransfer indicates an expected call of GetScheduledTransfer.
+func (mr *MockStoreMockRecorder) GetScheduledTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduledTransfer", reflect.TypeOf((*MockStore)(nil).GetScheduledTransfer), arg0, arg1)
+}
+
+// GetSession mocks base method.
+func (m *MockStore) GetSession(arg0 context.Context, arg1 uuid.UUID) (repo.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", arg0, arg1)
+	ret0, _ := ret[0].(repo.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MockStoreMockRecorder) GetSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockStore)(nil).GetSession), arg0, arg1)
+}
+
 // GetTransfer mocks base method.
 func (m *MockStore) GetTransfer(arg0 context.Context, arg1 int64) (repo.Transfer, error) {
 	m.ctrl.T.Helper()
@@ -213,6 +678,21 @@ func (mr *MockStoreMockRecorder) GetTransfer(arg0, arg1 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfer", reflect.TypeOf((*MockStore)(nil).GetTransfer), arg0, arg1)
 }
 
+// GetTransferForUpdate mocks base method.
+func (m *MockStore) GetTransferForUpdate(arg0 context.Context, arg1 int64) (repo.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(repo.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferForUpdate indicates an expected call of GetTransferForUpdate.
+func (mr *MockStoreMockRecorder) GetTransferForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferForUpdate", reflect.TypeOf((*MockStore)(nil).GetTransferForUpdate), arg0, arg1)
+}
+
 // GetUser mocks base method.
 func (m *MockStore) GetUser(arg0 context.Context, arg1 string) (repo.User, error) {
 	m.ctrl.T.Helper()
@@ -228,6 +708,36 @@ func (mr *MockStoreMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), arg0, arg1)
 }
 
+// IsSessionBlocked mocks base method.
+func (m *MockStore) IsSessionBlocked(arg0 context.Context, arg1 uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsSessionBlocked", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsSessionBlocked indicates an expected call of IsSessionBlocked.
+func (mr *MockStoreMockRecorder) IsSessionBlocked(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSessionBlocked", reflect.TypeOf((*MockStore)(nil).IsSessionBlocked), arg0, arg1)
+}
+
+// IsTokenRevoked mocks base method.
+func (m *MockStore) IsTokenRevoked(arg0 context.Context, arg1 uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsTokenRevoked", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsTokenRevoked indicates an expected call of IsTokenRevoked.
+func (mr *MockStoreMockRecorder) IsTokenRevoked(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTokenRevoked", reflect.TypeOf((*MockStore)(nil).IsTokenRevoked), arg0, arg1)
+}
+
 // ListAccounts mocks base method.
 func (m *MockStore) ListAccounts(arg0 context.Context, arg1 repo.ListAccountsParams) ([]repo.Account, error) {
 	m.ctrl.T.Helper()
@@ -243,6 +753,51 @@ func (mr *MockStoreMockRecorder) ListAccounts(arg0, arg1 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockStore)(nil).ListAccounts), arg0, arg1)
 }
 
+// ListBalanceMismatches mocks base method.
+func (m *MockStore) ListBalanceMismatches(arg0 context.Context) ([]repo.ListBalanceMismatchesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBalanceMismatches", arg0)
+	ret0, _ := ret[0].([]repo.ListBalanceMismatchesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBalanceMismatches indicates an expected call of ListBalanceMismatches.
+func (mr *MockStoreMockRecorder) ListBalanceMismatches(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBalanceMismatches", reflect.TypeOf((*MockStore)(nil).ListBalanceMismatches), arg0)
+}
+
+// ListCurrencies mocks base method.
+func (m *MockStore) ListCurrencies(arg0 context.Context) ([]repo.Currency, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCurrencies", arg0)
+	ret0, _ := ret[0].([]repo.Currency)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCurrencies indicates an expected call of ListCurrencies.
+func (mr *MockStoreMockRecorder) ListCurrencies(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCurrencies", reflect.TypeOf((*MockStore)(nil).ListCurrencies), arg0)
+}
+
+// ListEffectiveTransferLimits mocks base method.
+func (m *MockStore) ListEffectiveTransferLimits(arg0 context.Context, arg1 string) ([]repo.ListEffectiveTransferLimitsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEffectiveTransferLimits", arg0, arg1)
+	ret0, _ := ret[0].([]repo.ListEffectiveTransferLimitsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEffectiveTransferLimits indicates an expected call of ListEffectiveTransferLimits.
+func (mr *MockStoreMockRecorder) ListEffectiveTransferLimits(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEffectiveTransferLimits", reflect.TypeOf((*MockStore)(nil).ListEffectiveTransferLimits), arg0, arg1)
+}
+
 // ListEntries mocks base method.
 func (m *MockStore) ListEntries(arg0 context.Context, arg1 repo.ListEntriesParams) ([]repo.Entry, error) {
 	m.ctrl.T.Helper()
@@ -259,10 +814,10 @@ func (mr *MockStoreMockRecorder) ListEntries(arg0, arg1 interface{}) *gomock.Cal
 }
 
 // ListEntriesByAccountID mocks base method.
-func (m *MockStore) ListEntriesByAccountID(arg0 context.Context, arg1 repo.ListEntriesByAccountIDParams) ([]repo.Entry, error) {
+func (m *MockStore) ListEntriesByAccountID(arg0 context.Context, arg1 repo.ListEntriesByAccountIDParams) ([]repo.ListEntriesByAccountIDRow, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListEntriesByAccountID", arg0, arg1)
-	ret0, _ := ret[0].([]repo.Entry)
+	ret0, _ := ret[0].([]repo.ListEntriesByAccountIDRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -273,6 +828,51 @@ func (mr *MockStoreMockRecorder) ListEntriesByAccountID(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntriesByAccountID", reflect.TypeOf((*MockStore)(nil).ListEntriesByAccountID), arg0, arg1)
 }
 
+// ListScheduledTransferRuns mocks base method.
+func (m *MockStore) ListScheduledTransferRuns(arg0 context.Context, arg1 repo.ListScheduledTransferRunsParams) ([]repo.ScheduledTransferRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScheduledTransferRuns", arg0, arg1)
+	ret0, _ := ret[0].([]repo.ScheduledTransferRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListScheduledTransferRuns indicates an expected call of ListScheduledTransferRuns.
+func (mr *MockStoreMockRecorder) ListScheduledTransferRuns(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduledTransferRuns", reflect.TypeOf((*MockStore)(nil).ListScheduledTransferRuns), arg0, arg1)
+}
+
+// ListScheduledTransfers mocks base method.
+func (m *MockStore) ListScheduledTransfers(arg0 context.Context, arg1 repo.ListScheduledTransfersParams) ([]repo.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScheduledTransfers", arg0, arg1)
+	ret0, _ := ret[0].([]repo.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListScheduledTransfers indicates an expected call of ListScheduledTransfers.
+func (mr *MockStoreMockRecorder) ListScheduledTransfers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduledTransfers", reflect.TypeOf((*MockStore)(nil).ListScheduledTransfers), arg0, arg1)
+}
+
+// ListStatementLines mocks base method.
+func (m *MockStore) ListStatementLines(arg0 context.Context, arg1 repo.ListStatementLinesParams) ([]repo.ListStatementLinesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStatementLines", arg0, arg1)
+	ret0, _ := ret[0].([]repo.ListStatementLinesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStatementLines indicates an expected call of ListStatementLines.
+func (mr *MockStoreMockRecorder) ListStatementLines(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStatementLines", reflect.TypeOf((*MockStore)(nil).ListStatementLines), arg0, arg1)
+}
+
 // ListTransfers mocks base method.
 func (m *MockStore) ListTransfers(arg0 context.Context, arg1 repo.ListTransfersParams) ([]repo.Transfer, error) {
 	m.ctrl.T.Helper()
@@ -288,6 +888,109 @@ func (mr *MockStoreMockRecorder) ListTransfers(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfers", reflect.TypeOf((*MockStore)(nil).ListTransfers), arg0, arg1)
 }
 
+// ListUnbalancedTransfers mocks base method.
+func (m *MockStore) ListUnbalancedTransfers(arg0 context.Context) ([]repo.ListUnbalancedTransfersRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUnbalancedTransfers", arg0)
+	ret0, _ := ret[0].([]repo.ListUnbalancedTransfersRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUnbalancedTransfers indicates an expected call of ListUnbalancedTransfers.
+func (mr *MockStoreMockRecorder) ListUnbalancedTransfers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUnbalancedTransfers", reflect.TypeOf((*MockStore)(nil).ListUnbalancedTransfers), arg0)
+}
+
+// LoadRatesTx mocks base method.
+func (m *MockStore) LoadRatesTx(arg0 context.Context, arg1 []repo.CreateRateParams) ([]repo.Rate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadRatesTx", arg0, arg1)
+	ret0, _ := ret[0].([]repo.Rate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadRatesTx indicates an expected call of LoadRatesTx.
+func (mr *MockStoreMockRecorder) LoadRatesTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadRatesTx", reflect.TypeOf((*MockStore)(nil).LoadRatesTx), arg0, arg1)
+}
+
+// Ping mocks base method.
+func (m *MockStore) Ping(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockStoreMockRecorder) Ping(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockStore)(nil).Ping), arg0)
+}
+
+// RescheduleScheduledTransfer mocks base method.
+func (m *MockStore) RescheduleScheduledTransfer(arg0 context.Context, arg1 repo.RescheduleScheduledTransferParams) (repo.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RescheduleScheduledTransfer", arg0, arg1)
+	ret0, _ := ret[0].(repo.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RescheduleScheduledTransfer indicates an expected call of RescheduleScheduledTransfer.
+func (mr *MockStoreMockRecorder) RescheduleScheduledTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RescheduleScheduledTransfer", reflect.TypeOf((*MockStore)(nil).RescheduleScheduledTransfer), arg0, arg1)
+}
+
+// ReverseTransferTx mocks base method.
+func (m *MockStore) ReverseTransferTx(arg0 context.Context, arg1 repo.ReverseTransferTxParams) (repo.TransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReverseTransferTx", arg0, arg1)
+	ret0, _ := ret[0].(repo.TransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReverseTransferTx indicates an expected call of ReverseTransferTx.
+func (mr *MockStoreMockRecorder) ReverseTransferTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseTransferTx", reflect.TypeOf((*MockStore)(nil).ReverseTransferTx), arg0, arg1)
+}
+
+// RevokeToken mocks base method.
+func (m *MockStore) RevokeToken(arg0 context.Context, arg1 repo.RevokeTokenParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeToken indicates an expected call of RevokeToken.
+func (mr *MockStoreMockRecorder) RevokeToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockStore)(nil).RevokeToken), arg0, arg1)
+}
+
+// RunScheduledTransferTx mocks base method.
+func (m *MockStore) RunScheduledTransferTx(arg0 context.Context, arg1 time.Time) (repo.ScheduledTransferRunResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunScheduledTransferTx", arg0, arg1)
+	ret0, _ := ret[0].(repo.ScheduledTransferRunResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunScheduledTransferTx indicates an expected call of RunScheduledTransferTx.
+func (mr *MockStoreMockRecorder) RunScheduledTransferTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunScheduledTransferTx", reflect.TypeOf((*MockStore)(nil).RunScheduledTransferTx), arg0, arg1)
+}
+
 // TransferTx mocks base method.
 func (m *MockStore) TransferTx(arg0 context.Context, arg1 repo.TransferTxParams) (repo.TransferTxResult, error) {
 	m.ctrl.T.Helper()
@@ -318,6 +1021,36 @@ func (mr *MockStoreMockRecorder) UpdateAccount(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccount", reflect.TypeOf((*MockStore)(nil).UpdateAccount), arg0, arg1)
 }
 
+// UpdateAccountOverdraftLimit mocks base method.
+func (m *MockStore) UpdateAccountOverdraftLimit(arg0 context.Context, arg1 repo.UpdateAccountOverdraftLimitParams) (repo.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccountOverdraftLimit", arg0, arg1)
+	ret0, _ := ret[0].(repo.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAccountOverdraftLimit indicates an expected call of UpdateAccountOverdraftLimit.
+func (mr *MockStoreMockRecorder) UpdateAccountOverdraftLimit(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountOverdraftLimit", reflect.TypeOf((*MockStore)(nil).UpdateAccountOverdraftLimit), arg0, arg1)
+}
+
+// UpdateAccountStatus mocks base method.
+func (m *MockStore) UpdateAccountStatus(arg0 context.Context, arg1 repo.UpdateAccountStatusParams) (repo.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccountStatus", arg0, arg1)
+	ret0, _ := ret[0].(repo.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAccountStatus indicates an expected call of UpdateAccountStatus.
+func (mr *MockStoreMockRecorder) UpdateAccountStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountStatus", reflect.TypeOf((*MockStore)(nil).UpdateAccountStatus), arg0, arg1)
+}
+
 // UpdateEntry mocks base method.
 func (m *MockStore) UpdateEntry(arg0 context.Context, arg1 repo.UpdateEntryParams) (repo.Entry, error) {
 	m.ctrl.T.Helper()
@@ -332,3 +1065,123 @@ func (mr *MockStoreMockRecorder) UpdateEntry(arg0, arg1 interface{}) *gomock.Cal
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEntry", reflect.TypeOf((*MockStore)(nil).UpdateEntry), arg0, arg1)
 }
+
+// UpdateScheduledTransfer mocks base method.
+func (m *MockStore) UpdateScheduledTransfer(arg0 context.Context, arg1 repo.UpdateScheduledTransferParams) (repo.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateScheduledTransfer", arg0, arg1)
+	ret0, _ := ret[0].(repo.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateScheduledTransfer indicates an expected call of UpdateScheduledTransfer.
+func (mr *MockStoreMockRecorder) UpdateScheduledTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateScheduledTransfer", reflect.TypeOf((*MockStore)(nil).UpdateScheduledTransfer), arg0, arg1)
+}
+
+// UpdateTransferStatus mocks base method.
+func (m *MockStore) UpdateTransferStatus(arg0 context.Context, arg1 repo.UpdateTransferStatusParams) (repo.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTransferStatus", arg0, arg1)
+	ret0, _ := ret[0].(repo.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTransferStatus indicates an expected call of UpdateTransferStatus.
+func (mr *MockStoreMockRecorder) UpdateTransferStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTransferStatus", reflect.TypeOf((*MockStore)(nil).UpdateTransferStatus), arg0, arg1)
+}
+
+// UpsertTransferLimit mocks base method.
+func (m *MockStore) UpsertTransferLimit(arg0 context.Context, arg1 repo.UpsertTransferLimitParams) (repo.TransferLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertTransferLimit", arg0, arg1)
+	ret0, _ := ret[0].(repo.TransferLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertTransferLimit indicates an expected call of UpsertTransferLimit.
+func (mr *MockStoreMockRecorder) UpsertTransferLimit(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertTransferLimit", reflect.TypeOf((*MockStore)(nil).UpsertTransferLimit), arg0, arg1)
+}
+
+// UpsertUserTransferLimit mocks base method.
+func (m *MockStore) UpsertUserTransferLimit(arg0 context.Context, arg1 repo.UpsertUserTransferLimitParams) (repo.UserTransferLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertUserTransferLimit", arg0, arg1)
+	ret0, _ := ret[0].(repo.UserTransferLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertUserTransferLimit indicates an expected call of UpsertUserTransferLimit.
+func (mr *MockStoreMockRecorder) UpsertUserTransferLimit(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertUserTransferLimit", reflect.TypeOf((*MockStore)(nil).UpsertUserTransferLimit), arg0, arg1)
+}
+
+// VerifyLedger mocks base method.
+func (m *MockStore) VerifyLedger(arg0 context.Context) (repo.LedgerReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyLedger", arg0)
+	ret0, _ := ret[0].(repo.LedgerReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyLedger indicates an expected call of VerifyLedger.
+func (mr *MockStoreMockRecorder) VerifyLedger(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyLedger", reflect.TypeOf((*MockStore)(nil).VerifyLedger), arg0)
+}
+
+// VoidExpiredTransferTx mocks base method.
+func (m *MockStore) VoidExpiredTransferTx(arg0 context.Context, arg1 time.Time) (repo.PendingTransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VoidExpiredTransferTx", arg0, arg1)
+	ret0, _ := ret[0].(repo.PendingTransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VoidExpiredTransferTx indicates an expected call of VoidExpiredTransferTx.
+func (mr *MockStoreMockRecorder) VoidExpiredTransferTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VoidExpiredTransferTx", reflect.TypeOf((*MockStore)(nil).VoidExpiredTransferTx), arg0, arg1)
+}
+
+// VoidTransferTx mocks base method.
+func (m *MockStore) VoidTransferTx(arg0 context.Context, arg1 int64) (repo.PendingTransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VoidTransferTx", arg0, arg1)
+	ret0, _ := ret[0].(repo.PendingTransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VoidTransferTx indicates an expected call of VoidTransferTx.
+func (mr *MockStoreMockRecorder) VoidTransferTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VoidTransferTx", reflect.TypeOf((*MockStore)(nil).VoidTransferTx), arg0, arg1)
+}
+
+// WithdrawTx mocks base method.
+func (m *MockStore) WithdrawTx(arg0 context.Context, arg1 repo.BalanceChangeTxParams) (repo.BalanceChangeTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithdrawTx", arg0, arg1)
+	ret0, _ := ret[0].(repo.BalanceChangeTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WithdrawTx indicates an expected call of WithdrawTx.
+func (mr *MockStoreMockRecorder) WithdrawTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithdrawTx", reflect.TypeOf((*MockStore)(nil).WithdrawTx), arg0, arg1)
+}
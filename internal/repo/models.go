@@ -5,7 +5,10 @@
 package repo
 
 import (
+	"encoding/json"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type Account struct {
@@ -14,6 +17,23 @@ type Account struct {
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	// how far below zero the balance may go
+	OverdraftLimit int64 `json:"overdraft_limit"`
+	// active, frozen or closed, only active accounts can move money
+	Status string `json:"status"`
+	// sum of the pending transfers from the account
+	HeldAmount int64 `json:"held_amount"`
+	// balance which can be spent, held money excluded
+	AvailableBalance int64 `json:"available_balance"`
+}
+
+type Currency struct {
+	// ISO 4217 alphabetic code
+	Code string `json:"code"`
+	// number of digits after the decimal separator, amounts are stored in minor units
+	Exponent  int32     `json:"exponent"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type Entry struct {
@@ -22,6 +42,84 @@ type Entry struct {
 	// can be negative or positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// transfer which created the entry, empty for entries not caused by a transfer
+	TransferID *int64 `json:"transfer_id"`
+	// transfer, deposit, withdrawal or adjustment made by an admin
+	Kind string `json:"kind"`
+	// copied from the transfer
+	Description string `json:"description"`
+	// copied from the transfer
+	ExternalReference string `json:"external_reference"`
+	// copied from the transfer
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+type IdempotencyKey struct {
+	Username    string `json:"username"`
+	Key         string `json:"key"`
+	RequestHash string `json:"request_hash"`
+	// serialized result replayed on retries
+	Response  json.RawMessage `json:"response"`
+	CreatedAt time.Time       `json:"created_at"`
+	// the key may be deleted after it, a retry is not expected anymore
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type Rate struct {
+	ID            int64  `json:"id"`
+	BaseCurrency  string `json:"base_currency"`
+	QuoteCurrency string `json:"quote_currency"`
+	// amount of quote currency for one unit of base currency
+	Rate      string    `json:"rate"`
+	ValidFrom time.Time `json:"valid_from"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RevokedToken struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ScheduledTransfer struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+	// day, week or month, the transfer runs every interval_count units
+	IntervalUnit  string    `json:"interval_unit"`
+	IntervalCount int32     `json:"interval_count"`
+	NextRunAt     time.Time `json:"next_run_at"`
+	// no run is scheduled after it, empty for transfers without an end
+	EndDate   *time.Time `json:"end_date"`
+	Active    bool       `json:"active"`
+	CreatedAt time.Time  `json:"created_at"`
+	// first run of the schedule, every run is counted from it so a short month does not shift the next ones
+	StartAt time.Time `json:"start_at"`
+}
+
+type ScheduledTransferRun struct {
+	ID                  int64 `json:"id"`
+	ScheduledTransferID int64 `json:"scheduled_transfer_id"`
+	// transfer made by the run, empty when the run failed
+	TransferID *int64 `json:"transfer_id"`
+	// why the run failed, empty when it succeeded
+	Error     string    `json:"error"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Session struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	IsBlocked    bool      `json:"is_blocked"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 type Transfer struct {
@@ -30,6 +128,38 @@ type Transfer struct {
 	ToAccountID   int64     `json:"to_account_id"`
 	Amount        int64     `json:"amount"`
 	CreatedAt     time.Time `json:"created_at"`
+	// credited to the destination account in its currency
+	ToAmount int64 `json:"to_amount"`
+	// exchange rate applied to the amount, 1 for transfers in the same currency
+	Rate string `json:"rate"`
+	// transfer refunded by this one, a transfer may be refunded in several parts
+	ReversalOf *int64 `json:"reversal_of"`
+	// shown to both parties on their statements
+	Description string `json:"description"`
+	// reference of the transfer in the client system, like an invoice number
+	ExternalReference string `json:"external_reference"`
+	// arbitrary string values set by the client
+	Metadata json.RawMessage `json:"metadata"`
+	// pending while the money is held, posted once moved or voided when the hold is released
+	Status string `json:"status"`
+	// pending transfers are voided after it
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// default limits of outgoing transfers per currency, 0 means no limit
+type TransferLimit struct {
+	Currency string `json:"currency"`
+	// max amount of a single transfer
+	MaxAmount int64 `json:"max_amount"`
+	// max total sent from one account per UTC day
+	AccountDailyLimit int64 `json:"account_daily_limit"`
+	// max total sent from one account per UTC month
+	AccountMonthlyLimit int64 `json:"account_monthly_limit"`
+	// max total sent from all accounts of a user in the currency per UTC day
+	UserDailyLimit int64 `json:"user_daily_limit"`
+	// max total sent from all accounts of a user in the currency per UTC month
+	UserMonthlyLimit int64     `json:"user_monthly_limit"`
+	UpdatedAt        time.Time `json:"updated_at"`
 }
 
 type User struct {
@@ -39,4 +169,17 @@ type User struct {
 	HashPassword     string    `json:"hash_password"`
 	ChangePasswordAt time.Time `json:"change_password_at"`
 	CreatedAt        time.Time `json:"created_at"`
+	Role             string    `json:"role"`
+}
+
+// limits set by an admin for a user, they replace the defaults of the currency
+type UserTransferLimit struct {
+	Username            string    `json:"username"`
+	Currency            string    `json:"currency"`
+	MaxAmount           int64     `json:"max_amount"`
+	AccountDailyLimit   int64     `json:"account_daily_limit"`
+	AccountMonthlyLimit int64     `json:"account_monthly_limit"`
+	UserDailyLimit      int64     `json:"user_daily_limit"`
+	UserMonthlyLimit    int64     `json:"user_monthly_limit"`
+	UpdatedAt           time.Time `json:"updated_at"`
 }
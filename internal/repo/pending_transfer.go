@@ -0,0 +1,208 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Transfer statuses. A transfer is posted right away, unless it's authorized first:
+// then it stays pending with the amount held on the source account until it's captured or voided.
+const (
+	TransferStatusPending = "pending"
+	TransferStatusPosted  = "posted"
+	TransferStatusVoided  = "voided"
+)
+
+type AuthorizeTransferTxParams struct {
+	TransferTxParams
+	// Exchange allows accounts in different currencies, the rate is fixed at authorization
+	Exchange bool `json:"exchange"`
+	// ExpiresAt is when the hold is released, unless the transfer is captured before
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PendingTransferTxResult is returned when the money is held or released, no entries are recorded then
+type PendingTransferTxResult struct {
+	Transfer    Transfer `json:"transfer"`
+	FromAccount Account  `json:"from_account"`
+	ToAccount   Account  `json:"to_account"`
+}
+
+// AuthorizeTransferTx holds the amount on the source account with a pending transfer.
+// It runs the same checks as TransferTx, the held money counts against the limits and can't be spent until it's released.
+func (s *SQLStore) AuthorizeTransferTx(ctx context.Context, arg AuthorizeTransferTxParams) (PendingTransferTxResult, error) {
+	var result PendingTransferTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		result = PendingTransferTxResult{}
+		params, _, toAccount, err := prepareTransfer(ctx, q, arg.TransferTxParams, arg.Exchange)
+		if err != nil {
+			return err
+		}
+
+		result.Transfer, err = q.CreatePendingTransfer(ctx, CreatePendingTransferParams{
+			FromAccountID:     params.FromAccountID,
+			ToAccountID:       params.ToAccountID,
+			Amount:            params.Amount,
+			ToAmount:          params.ToAmount,
+			Rate:              params.Rate,
+			Description:       params.Description,
+			ExternalReference: params.ExternalReference,
+			Metadata:          metadataOrEmpty(params.Metadata),
+			ExpiresAt:         &arg.ExpiresAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.FromAccount, err = q.AddAccountHold(ctx, AddAccountHoldParams{
+			Amount: params.Amount,
+			ID:     params.FromAccountID,
+		})
+		if err != nil {
+			return err
+		}
+		result.ToAccount = toAccount
+
+		if arg.Idempotency != nil {
+			return storeIdempotencyKey(ctx, q, arg.Idempotency, result)
+		}
+
+		return nil
+	})
+
+	return result, wrapError(err)
+}
+
+// CaptureTransferTx posts a pending transfer: the hold is released and the money moves
+// with the amounts fixed at authorization. An expired hold can't be captured anymore.
+func (s *SQLStore) CaptureTransferTx(ctx context.Context, transferID int64) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		pending, err := lockPendingTransfer(ctx, q, transferID)
+		if err != nil {
+			return err
+		}
+		if pending.ExpiresAt != nil && !time.Now().Before(*pending.ExpiresAt) {
+			return fmt.Errorf("%w: hold of transfer %d has expired", ErrInvalidTransferStatus, pending.ID)
+		}
+
+		// the same lock order as transferTx avoids deadlocks with transfers between the same accounts
+		var fromAccount, toAccount Account
+		if pending.FromAccountID < pending.ToAccountID {
+			fromAccount, toAccount, err = lockAccounts(ctx, q, pending.FromAccountID, pending.ToAccountID)
+		} else {
+			toAccount, fromAccount, err = lockAccounts(ctx, q, pending.ToAccountID, pending.FromAccountID)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = requireActive(fromAccount); err != nil {
+			return err
+		}
+		if err = requireActive(toAccount); err != nil {
+			return err
+		}
+
+		// the released hold is spent right away, so the available balance doesn't change
+		_, err = q.AddAccountHold(ctx, AddAccountHoldParams{
+			Amount: -pending.Amount,
+			ID:     pending.FromAccountID,
+		})
+		if err != nil {
+			return err
+		}
+
+		posted, err := q.UpdateTransferStatus(ctx, UpdateTransferStatusParams{
+			Status: TransferStatusPosted,
+			ID:     pending.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		result, err = postEntries(ctx, q, posted)
+		return err
+	})
+	if err != nil {
+		return result, wrapError(err)
+	}
+
+	observePosted(result.FromAccount.Currency, result.Transfer.Amount)
+	return result, nil
+}
+
+// VoidTransferTx releases the hold of a pending transfer, no money moves
+func (s *SQLStore) VoidTransferTx(ctx context.Context, transferID int64) (PendingTransferTxResult, error) {
+	var result PendingTransferTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		pending, err := lockPendingTransfer(ctx, q, transferID)
+		if err != nil {
+			return err
+		}
+
+		result, err = voidTransfer(ctx, q, pending)
+		return err
+	})
+
+	return result, wrapError(err)
+}
+
+// VoidExpiredTransferTx releases the hold of one pending transfer which expired by now.
+// Transfers locked by another transaction are skipped, so several sweepers can run at once.
+// It returns ErrRecordNotFound when no hold has expired.
+func (s *SQLStore) VoidExpiredTransferTx(ctx context.Context, now time.Time) (PendingTransferTxResult, error) {
+	var result PendingTransferTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		pending, err := q.GetExpiredPendingTransfer(ctx, now)
+		if err != nil {
+			return err
+		}
+
+		result, err = voidTransfer(ctx, q, pending)
+		return err
+	})
+
+	return result, wrapError(err)
+}
+
+// lockPendingTransfer locks the transfer, so it can't be captured and voided at the same time
+func lockPendingTransfer(ctx context.Context, q *Queries, transferID int64) (Transfer, error) {
+	transfer, err := q.GetTransferForUpdate(ctx, transferID)
+	if err != nil {
+		return transfer, err
+	}
+
+	if transfer.Status != TransferStatusPending {
+		return transfer, fmt.Errorf("%w: transfer %d is %s", ErrInvalidTransferStatus, transfer.ID, transfer.Status)
+	}
+	return transfer, nil
+}
+
+// voidTransfer releases the hold of the locked pending transfer. Holds are released
+// from frozen and closed accounts too, it only gives the money back to the owner.
+func voidTransfer(ctx context.Context, q *Queries, pending Transfer) (result PendingTransferTxResult, err error) {
+	result.FromAccount, err = q.AddAccountHold(ctx, AddAccountHoldParams{
+		Amount: -pending.Amount,
+		ID:     pending.FromAccountID,
+	})
+	if err != nil {
+		return
+	}
+
+	result.ToAccount, err = q.GetAccount(ctx, pending.ToAccountID)
+	if err != nil {
+		return
+	}
+
+	result.Transfer, err = q.UpdateTransferStatus(ctx, UpdateTransferStatusParams{
+		Status: TransferStatusVoided,
+		ID:     pending.ID,
+	})
+	return
+}
@@ -0,0 +1,134 @@
+package repo
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func authorizeTransfer(t *testing.T, store Store, from, to Account, amount int64, expiresAt time.Time) PendingTransferTxResult {
+	result, err := store.AuthorizeTransferTx(context.Background(), AuthorizeTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: from.ID,
+			ToAccountID:   to.ID,
+			Amount:        amount,
+		},
+		ExpiresAt: expiresAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, TransferStatusPending, result.Transfer.Status)
+	require.NotNil(t, result.Transfer.ExpiresAt)
+
+	return result
+}
+
+func TestStore_CaptureTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
+
+	pending := authorizeTransfer(t, store, account1, account2, 300, time.Now().Add(time.Hour))
+	require.Equal(t, int64(1000), pending.FromAccount.Balance)
+	require.Equal(t, int64(300), pending.FromAccount.HeldAmount)
+	require.Equal(t, int64(700), pending.FromAccount.AvailableBalance)
+
+	// the held money can't be spent
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        701,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// a pending transfer isn't reversed, it's voided
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{TransferID: pending.Transfer.ID})
+	require.ErrorIs(t, err, ErrInvalidTransferStatus)
+
+	captured, err := store.CaptureTransferTx(context.Background(), pending.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, TransferStatusPosted, captured.Transfer.Status)
+	require.Equal(t, int64(-300), captured.FromEntry.Amount)
+	require.Equal(t, int64(300), captured.ToEntry.Amount)
+	require.Equal(t, int64(700), captured.FromAccount.Balance)
+	require.Zero(t, captured.FromAccount.HeldAmount)
+	require.Equal(t, int64(700), captured.FromAccount.AvailableBalance)
+	require.Equal(t, int64(1300), captured.ToAccount.Balance)
+
+	_, err = store.CaptureTransferTx(context.Background(), pending.Transfer.ID)
+	require.ErrorIs(t, err, ErrInvalidTransferStatus)
+	_, err = store.VoidTransferTx(context.Background(), pending.Transfer.ID)
+	require.ErrorIs(t, err, ErrInvalidTransferStatus)
+}
+
+func TestStore_AuthorizeTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
+
+	authorizeTransfer(t, store, account1, account2, 600, time.Now().Add(time.Hour))
+
+	_, err := store.AuthorizeTransferTx(context.Background(), AuthorizeTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        401,
+		},
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.WithdrawTx(context.Background(), BalanceChangeTxParams{AccountID: account1.ID, Amount: 401})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestStore_VoidTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
+
+	pending := authorizeTransfer(t, store, account1, account2, 300, time.Now().Add(time.Hour))
+
+	voided, err := store.VoidTransferTx(context.Background(), pending.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, TransferStatusVoided, voided.Transfer.Status)
+	require.Equal(t, int64(1000), voided.FromAccount.Balance)
+	require.Zero(t, voided.FromAccount.HeldAmount)
+	require.Equal(t, int64(1000), voided.ToAccount.Balance)
+
+	_, err = store.CaptureTransferTx(context.Background(), pending.Transfer.ID)
+	require.ErrorIs(t, err, ErrInvalidTransferStatus)
+
+	// no entries are recorded for a voided transfer
+	_, err = store.GetEntryByAccountID(context.Background(), account1.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestStore_VoidExpiredTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
+
+	expiresAt := time.Now().Add(time.Minute)
+	pending := authorizeTransfer(t, store, account1, account2, 300, expiresAt)
+
+	// other tests leave expired holds behind, so the sweeper runs until ours is released
+	now := expiresAt.Add(time.Second)
+	for {
+		result, err := store.VoidExpiredTransferTx(context.Background(), now)
+		require.NoError(t, err)
+		require.Equal(t, TransferStatusVoided, result.Transfer.Status)
+
+		if result.Transfer.ID == pending.Transfer.ID {
+			require.Zero(t, result.FromAccount.HeldAmount)
+			break
+		}
+	}
+
+	_, err := store.CaptureTransferTx(context.Background(), pending.Transfer.ID)
+	require.ErrorIs(t, err, ErrInvalidTransferStatus)
+}
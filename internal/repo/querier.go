@@ -6,28 +6,74 @@ package repo
 
 import (
 	"context"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	AddAccountHold(ctx context.Context, arg AddAccountHoldParams) (Account, error)
+	BlockSession(ctx context.Context, id uuid.UUID) (Session, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error)
+	CreatePendingTransfer(ctx context.Context, arg CreatePendingTransferParams) (Transfer, error)
+	CreateRate(ctx context.Context, arg CreateRateParams) (Rate, error)
+	CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfer, error)
+	CreateScheduledTransferRun(ctx context.Context, arg CreateScheduledTransferRunParams) (ScheduledTransferRun, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	DeleteAccount(ctx context.Context, id int64) error
 	DeleteEntry(ctx context.Context, accountID int64) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, expiresAt time.Time) (int64, error)
+	DeleteExpiredRevokedTokens(ctx context.Context, expiresAt time.Time) (int64, error)
+	DeleteExpiredSessions(ctx context.Context, expiresAt time.Time) (int64, error)
+	DeleteScheduledTransfer(ctx context.Context, id int64) error
+	DeleteUserTransferLimit(ctx context.Context, arg DeleteUserTransferLimitParams) error
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountBalanceAt(ctx context.Context, arg GetAccountBalanceAtParams) (int64, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetCurrency(ctx context.Context, code string) (Currency, error)
+	// rows locked by other workers are skipped, so several server instances never run the same transfer
+	GetDueScheduledTransfer(ctx context.Context, now time.Time) (ScheduledTransfer, error)
+	GetEffectiveTransferLimit(ctx context.Context, arg GetEffectiveTransferLimitParams) (GetEffectiveTransferLimitRow, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
 	GetEntryByAccountID(ctx context.Context, accountID int64) (Entry, error)
+	GetExpiredPendingTransfer(ctx context.Context, now time.Time) (Transfer, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetOutgoingTotals(ctx context.Context, arg GetOutgoingTotalsParams) (GetOutgoingTotalsRow, error)
+	GetRate(ctx context.Context, arg GetRateParams) (Rate, error)
+	GetReversedAmounts(ctx context.Context, reversalOf *int64) (GetReversedAmountsRow, error)
+	GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	IsSessionBlocked(ctx context.Context, id uuid.UUID) (bool, error)
+	IsTokenRevoked(ctx context.Context, id uuid.UUID) (bool, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListBalanceMismatches(ctx context.Context) ([]ListBalanceMismatchesRow, error)
+	ListCurrencies(ctx context.Context) ([]Currency, error)
+	ListEffectiveTransferLimits(ctx context.Context, username string) ([]ListEffectiveTransferLimitsRow, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
-	ListEntriesByAccountID(ctx context.Context, arg ListEntriesByAccountIDParams) ([]Entry, error)
+	ListEntriesByAccountID(ctx context.Context, arg ListEntriesByAccountIDParams) ([]ListEntriesByAccountIDRow, error)
+	ListScheduledTransferRuns(ctx context.Context, arg ListScheduledTransferRunsParams) ([]ScheduledTransferRun, error)
+	ListScheduledTransfers(ctx context.Context, arg ListScheduledTransfersParams) ([]ScheduledTransfer, error)
+	ListStatementLines(ctx context.Context, arg ListStatementLinesParams) ([]ListStatementLinesRow, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListUnbalancedTransfers(ctx context.Context) ([]ListUnbalancedTransfersRow, error)
+	RescheduleScheduledTransfer(ctx context.Context, arg RescheduleScheduledTransferParams) (ScheduledTransfer, error)
+	RevokeToken(ctx context.Context, arg RevokeTokenParams) error
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error)
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error)
 	UpdateEntry(ctx context.Context, arg UpdateEntryParams) (Entry, error)
+	UpdateScheduledTransfer(ctx context.Context, arg UpdateScheduledTransferParams) (ScheduledTransfer, error)
+	UpdateTransferStatus(ctx context.Context, arg UpdateTransferStatusParams) (Transfer, error)
+	UpsertTransferLimit(ctx context.Context, arg UpsertTransferLimitParams) (TransferLimit, error)
+	UpsertUserTransferLimit(ctx context.Context, arg UpsertUserTransferLimitParams) (UserTransferLimit, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"context"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomRate(t *testing.T, validFrom time.Time) Rate {
+	arg := CreateRateParams{
+		BaseCurrency:  util.USD,
+		QuoteCurrency: util.EUR,
+		Rate:          "0.9200000000",
+		ValidFrom:     validFrom,
+	}
+
+	rate, err := testQueries.CreateRate(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, rate.ID)
+	require.Equal(t, arg.BaseCurrency, rate.BaseCurrency)
+	require.Equal(t, arg.QuoteCurrency, rate.QuoteCurrency)
+	require.Equal(t, arg.Rate, rate.Rate)
+	require.WithinDuration(t, arg.ValidFrom, rate.ValidFrom, time.Second)
+
+	return rate
+}
+
+func TestQueries_CreateRate(t *testing.T) {
+	rate1 := createRandomRate(t, time.Now().Add(-time.Hour))
+
+	// loading the same pair and time again replaces the rate
+	rate2, err := testQueries.CreateRate(context.Background(), CreateRateParams{
+		BaseCurrency:  rate1.BaseCurrency,
+		QuoteCurrency: rate1.QuoteCurrency,
+		Rate:          "0.9300000000",
+		ValidFrom:     rate1.ValidFrom,
+	})
+	require.NoError(t, err)
+	require.Equal(t, rate1.ID, rate2.ID)
+	require.Equal(t, "0.9300000000", rate2.Rate)
+}
+
+func TestQueries_GetRate(t *testing.T) {
+	current := createRandomRate(t, time.Now())
+	createRandomRate(t, time.Now().Add(time.Hour))
+
+	// a rate from the future is not applied yet
+	rate, err := testQueries.GetRate(context.Background(), GetRateParams{
+		BaseCurrency:  util.USD,
+		QuoteCurrency: util.EUR,
+	})
+	require.NoError(t, err)
+	require.Equal(t, current.ID, rate.ID)
+}
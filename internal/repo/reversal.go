@@ -0,0 +1,128 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+)
+
+type ReverseTransferTxParams struct {
+	TransferID int64 `json:"transfer_id"`
+	// Amount is taken back from the destination account in its currency, zero reverses what's left of the transfer
+	Amount int64 `json:"amount"`
+}
+
+// ReverseTransferTx refunds a transfer, fully or in parts, with a compensating transfer in the opposite direction.
+// The original transfer is locked first, so concurrent reversals can't refund more than it moved.
+func (s *SQLStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		original, err := q.GetTransferForUpdate(ctx, arg.TransferID)
+		if err != nil {
+			return err
+		}
+		if original.Status != TransferStatusPosted {
+			return fmt.Errorf("%w: transfer %d is %s", ErrInvalidTransferStatus, original.ID, original.Status)
+		}
+		if original.ReversalOf != nil {
+			return fmt.Errorf("%w: transfer %d reverses transfer %d", ErrReversalOfReversal, original.ID, *original.ReversalOf)
+		}
+
+		reversed, err := q.GetReversedAmounts(ctx, &original.ID)
+		if err != nil {
+			return err
+		}
+
+		// the reversal moves money back, so its amount is in the currency of the original destination
+		remaining := original.ToAmount - reversed.Amount
+		amount := arg.Amount
+		if amount == 0 {
+			amount = remaining
+		}
+		if amount <= 0 || amount > remaining {
+			return fmt.Errorf("%w: %d left to reverse", ErrReversalExceedsTransfer, remaining)
+		}
+
+		toAmount := original.Amount - reversed.ToAmount
+		if amount < remaining {
+			toAmount, err = refundAmount(original, amount)
+			if err != nil {
+				return err
+			}
+		}
+
+		// the same lock order as transferTx avoids deadlocks with transfers between the same accounts
+		var fromAccount, toAccount Account
+		if original.ToAccountID < original.FromAccountID {
+			fromAccount, toAccount, err = lockAccounts(ctx, q, original.ToAccountID, original.FromAccountID)
+		} else {
+			toAccount, fromAccount, err = lockAccounts(ctx, q, original.FromAccountID, original.ToAccountID)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = requireActive(fromAccount); err != nil {
+			return err
+		}
+		if err = requireActive(toAccount); err != nil {
+			return err
+		}
+
+		if fromAccount.AvailableBalance+fromAccount.OverdraftLimit < amount {
+			return ErrInsufficientFunds
+		}
+
+		rate, err := inverseRate(original.Rate)
+		if err != nil {
+			return err
+		}
+
+		// the refund keeps the reference and metadata of the original, so the same search finds both
+		result, err = postTransfer(ctx, q, CreateTransferParams{
+			FromAccountID:     original.ToAccountID,
+			ToAccountID:       original.FromAccountID,
+			Amount:            amount,
+			ToAmount:          toAmount,
+			Rate:              rate,
+			ReversalOf:        &original.ID,
+			Description:       fmt.Sprintf("reversal of transfer %d", original.ID),
+			ExternalReference: original.ExternalReference,
+			Metadata:          original.Metadata,
+		})
+		return err
+	})
+
+	if err != nil {
+		return result, wrapError(err)
+	}
+
+	observePosted(result.FromAccount.Currency, result.Transfer.Amount)
+	return result, nil
+}
+
+// refundAmount returns the share of the original amount which matches the part of the credited amount
+// taken back. It's rounded down, so partial refunds never add up to more than the transfer moved.
+func refundAmount(original Transfer, amount int64) (int64, error) {
+	refund := new(big.Int).Mul(big.NewInt(amount), big.NewInt(original.Amount))
+	refund.Quo(refund, big.NewInt(original.ToAmount))
+
+	if refund.Sign() <= 0 {
+		return 0, fmt.Errorf("%w: %d of transfer %d", ErrAmountTooSmall, amount, original.ID)
+	}
+	return refund.Int64(), nil
+}
+
+// inverseRate reverses the exchange rate of a transfer, it fits the rate column of transfers
+func inverseRate(rate string) (string, error) {
+	if rate == sameCurrencyRate {
+		return sameCurrencyRate, nil
+	}
+
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok || r.Sign() <= 0 {
+		return "", fmt.Errorf("invalid rate %q", rate)
+	}
+	return r.Inv(r).FloatString(10), nil
+}
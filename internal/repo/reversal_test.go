@@ -0,0 +1,83 @@
+package repo
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestStore_ReverseTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
+
+	original, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        300,
+	})
+	require.NoError(t, err)
+
+	partial, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		Amount:     100,
+	})
+	require.NoError(t, err)
+	require.Equal(t, &original.Transfer.ID, partial.Transfer.ReversalOf)
+	require.Equal(t, account2.ID, partial.Transfer.FromAccountID)
+	require.Equal(t, account1.ID, partial.Transfer.ToAccountID)
+	require.Equal(t, int64(100), partial.Transfer.Amount)
+	require.Equal(t, int64(800), partial.ToAccount.Balance)
+	require.Equal(t, int64(1200), partial.FromAccount.Balance)
+
+	// only 200 is left to reverse
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		Amount:     201,
+	})
+	require.ErrorIs(t, err, ErrReversalExceedsTransfer)
+
+	rest, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(200), rest.Transfer.Amount)
+	require.Equal(t, account1.Balance, rest.ToAccount.Balance)
+	require.Equal(t, account2.Balance, rest.FromAccount.Balance)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+	})
+	require.ErrorIs(t, err, ErrReversalExceedsTransfer)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: rest.Transfer.ID,
+	})
+	require.ErrorIs(t, err, ErrReversalOfReversal)
+}
+
+func TestRefundAmount(t *testing.T) {
+	// 1000 was debited and 2750 credited, a third of the credit refunds a third of the debit rounded down
+	original := Transfer{ID: 1, Amount: 1000, ToAmount: 2750}
+
+	refund, err := refundAmount(original, 917)
+	require.NoError(t, err)
+	require.Equal(t, int64(333), refund)
+
+	_, err = refundAmount(original, 2)
+	require.ErrorIs(t, err, ErrAmountTooSmall)
+}
+
+func TestInverseRate(t *testing.T) {
+	rate, err := inverseRate(sameCurrencyRate)
+	require.NoError(t, err)
+	require.Equal(t, sameCurrencyRate, rate)
+
+	rate, err = inverseRate("0.2500000000")
+	require.NoError(t, err)
+	require.Equal(t, "4.0000000000", rate)
+
+	_, err = inverseRate("0")
+	require.Error(t, err)
+}
@@ -0,0 +1,57 @@
+package repo
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestQueries_RevokeToken(t *testing.T) {
+	user := createRandomUser(t)
+	tokenID := uuid.New()
+
+	revoked, err := testQueries.IsTokenRevoked(context.Background(), tokenID)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	arg := RevokeTokenParams{
+		ID:        tokenID,
+		Username:  user.Username,
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	err = testQueries.RevokeToken(context.Background(), arg)
+	require.NoError(t, err)
+
+	// revoking the same token twice must not fail
+	err = testQueries.RevokeToken(context.Background(), arg)
+	require.NoError(t, err)
+
+	revoked, err = testQueries.IsTokenRevoked(context.Background(), tokenID)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestQueries_DeleteExpiredRevokedTokens(t *testing.T) {
+	user := createRandomUser(t)
+	now := time.Now()
+
+	expired := RevokeTokenParams{ID: uuid.New(), Username: user.Username, ExpiresAt: now.Add(-time.Minute)}
+	valid := RevokeTokenParams{ID: uuid.New(), Username: user.Username, ExpiresAt: now.Add(time.Minute)}
+	require.NoError(t, testQueries.RevokeToken(context.Background(), expired))
+	require.NoError(t, testQueries.RevokeToken(context.Background(), valid))
+
+	deleted, err := testQueries.DeleteExpiredRevokedTokens(context.Background(), now)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(1))
+
+	revoked, err := testQueries.IsTokenRevoked(context.Background(), expired.ID)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	revoked, err = testQueries.IsTokenRevoked(context.Background(), valid.ID)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
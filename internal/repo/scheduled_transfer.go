@@ -0,0 +1,173 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// units of the interval between runs of a scheduled transfer
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// runFailures are the errors which fail a run of a scheduled transfer, but not the worker.
+// The run is recorded with the error and the transfer is rescheduled.
+var runFailures = []error{
+	ErrRecordNotFound,
+	ErrInsufficientFunds,
+	ErrCurrencyMismatch,
+	ErrRateNotFound,
+	ErrAmountTooSmall,
+	ErrAccountNotActive,
+	ErrLimitExceeded,
+}
+
+type ScheduledTransferRunResult struct {
+	ScheduledTransfer ScheduledTransfer    `json:"scheduled_transfer"`
+	Run               ScheduledTransferRun `json:"run"`
+}
+
+// NextRunAt returns the first run of the schedule after now. Runs missed while no worker was running
+// are skipped, a standing order runs at most once per check.
+// Every run is counted from the start of the schedule: a monthly run on the 31st falls on the last day
+// of shorter months and still runs on the 31st of the months after.
+func NextRunAt(schedule ScheduledTransfer, now time.Time) time.Time {
+	count := int(schedule.IntervalCount)
+
+	// the estimate is at most one interval ahead, the loop catches up from there
+	n := unitsBetween(schedule.StartAt, now, schedule.IntervalUnit)/count - 1
+	if n < 0 {
+		n = 0
+	}
+
+	next := addInterval(schedule.StartAt, schedule.IntervalUnit, n*count)
+	for !next.After(now) {
+		n++
+		next = addInterval(schedule.StartAt, schedule.IntervalUnit, n*count)
+	}
+	return next
+}
+
+func addInterval(t time.Time, unit string, count int) time.Time {
+	switch unit {
+	case IntervalWeek:
+		return t.AddDate(0, 0, 7*count)
+	case IntervalMonth:
+		return addMonths(t, count)
+	default:
+		return t.AddDate(0, 0, count)
+	}
+}
+
+// addMonths keeps the day of t, clamped to the last day of the month: January 31 plus a month is February 29 in 2024
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+
+	// day 0 of the next month is the last day of this one
+	if last := time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// unitsBetween is the number of whole interval units from start to t, it's negative when t is before start
+func unitsBetween(start, t time.Time, unit string) int {
+	switch unit {
+	case IntervalWeek:
+		return int(t.Sub(start).Hours() / (24 * 7))
+	case IntervalMonth:
+		return (t.Year()-start.Year())*12 + int(t.Month()-start.Month())
+	default:
+		return int(t.Sub(start).Hours() / 24)
+	}
+}
+
+// RunScheduledTransferTx executes one due scheduled transfer and reschedules it.
+// Due transfers locked by another worker are skipped, so several workers may run at the same time.
+// It returns ErrRecordNotFound when no transfer is due.
+func (s *SQLStore) RunScheduledTransferTx(ctx context.Context, now time.Time) (ScheduledTransferRunResult, error) {
+	var result ScheduledTransferRunResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		result = ScheduledTransferRunResult{}
+		schedule, err := q.GetDueScheduledTransfer(ctx, now)
+		if err != nil {
+			return err
+		}
+
+		transferID, err := runScheduledTransfer(ctx, q, schedule)
+		if err != nil && !isRunFailure(err) {
+			return err
+		}
+
+		result.Run, err = q.CreateScheduledTransferRun(ctx, CreateScheduledTransferRunParams{
+			ScheduledTransferID: schedule.ID,
+			TransferID:          transferID,
+			Error:               errorMessage(err),
+		})
+		if err != nil {
+			return err
+		}
+
+		next := NextRunAt(schedule, now)
+		result.ScheduledTransfer, err = q.RescheduleScheduledTransfer(ctx, RescheduleScheduledTransferParams{
+			NextRunAt: next,
+			Active:    schedule.EndDate == nil || !next.After(*schedule.EndDate),
+			ID:        schedule.ID,
+		})
+		return err
+	})
+	if err != nil {
+		return result, wrapError(err)
+	}
+
+	// a failed run is recorded without a transfer
+	if result.Run.TransferID != nil {
+		observePosted(result.ScheduledTransfer.Currency, result.ScheduledTransfer.Amount)
+	}
+	return result, nil
+}
+
+// runScheduledTransfer makes the transfer within a savepoint, so a failed transfer is rolled back
+// while the run is still recorded in the same transaction
+func runScheduledTransfer(ctx context.Context, q *Queries, schedule ScheduledTransfer) (*int64, error) {
+	if _, err := q.db.ExecContext(ctx, "savepoint scheduled_transfer"); err != nil {
+		return nil, err
+	}
+
+	result, err := transfer(ctx, q, TransferTxParams{
+		FromAccountID: schedule.FromAccountID,
+		ToAccountID:   schedule.ToAccountID,
+		Amount:        schedule.Amount,
+		Description:   fmt.Sprintf("scheduled transfer %d", schedule.ID),
+	}, true)
+	if err != nil {
+		if _, rbErr := q.db.ExecContext(ctx, "rollback to savepoint scheduled_transfer"); rbErr != nil {
+			return nil, fmt.Errorf("transfer err: %w, rb err: %v", err, rbErr)
+		}
+		return nil, wrapError(err)
+	}
+
+	return &result.Transfer.ID, nil
+}
+
+func isRunFailure(err error) bool {
+	for _, failure := range runFailures {
+		if errors.Is(err, failure) {
+			return true
+		}
+	}
+	return false
+}
+
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
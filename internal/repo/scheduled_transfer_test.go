@@ -0,0 +1,132 @@
+package repo
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createDueScheduledTransfer(t *testing.T, from, to Account, amount int64, endDate *time.Time) ScheduledTransfer {
+	schedule, err := testQueries.CreateScheduledTransfer(context.Background(), CreateScheduledTransferParams{
+		Owner:         from.Owner,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        amount,
+		Currency:      from.Currency,
+		IntervalUnit:  IntervalDay,
+		IntervalCount: 1,
+		NextRunAt:     time.Now().Add(-time.Hour),
+		EndDate:       endDate,
+	})
+	require.NoError(t, err)
+	require.True(t, schedule.StartAt.Equal(schedule.NextRunAt))
+
+	return schedule
+}
+
+// runDueScheduledTransfer runs due transfers until the schedule has run, others may be due from earlier tests
+func runDueScheduledTransfer(t *testing.T, store Store, schedule ScheduledTransfer) ScheduledTransferRunResult {
+	for i := 0; i < 100; i++ {
+		result, err := store.RunScheduledTransferTx(context.Background(), time.Now())
+		require.NoError(t, err)
+		if result.ScheduledTransfer.ID == schedule.ID {
+			return result
+		}
+	}
+
+	t.Fatalf("scheduled transfer %d didn't run", schedule.ID)
+	return ScheduledTransferRunResult{}
+}
+
+func TestStore_RunScheduledTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
+	schedule := createDueScheduledTransfer(t, account1, account2, 600, nil)
+
+	result := runDueScheduledTransfer(t, store, schedule)
+	require.NotNil(t, result.Run.TransferID)
+	require.Empty(t, result.Run.Error)
+	require.True(t, result.ScheduledTransfer.Active)
+	require.True(t, result.ScheduledTransfer.NextRunAt.After(time.Now()))
+	require.True(t, result.ScheduledTransfer.StartAt.Equal(schedule.StartAt))
+
+	transfer, err := store.GetTransfer(context.Background(), *result.Run.TransferID)
+	require.NoError(t, err)
+	require.Equal(t, int64(600), transfer.Amount)
+
+	// the second run can't be funded, it's recorded and the schedule goes on
+	_, err = testQueries.RescheduleScheduledTransfer(context.Background(), RescheduleScheduledTransferParams{
+		NextRunAt: time.Now().Add(-time.Hour),
+		Active:    true,
+		ID:        schedule.ID,
+	})
+	require.NoError(t, err)
+
+	result = runDueScheduledTransfer(t, store, schedule)
+	require.Nil(t, result.Run.TransferID)
+	require.Equal(t, ErrInsufficientFunds.Error(), result.Run.Error)
+	require.True(t, result.ScheduledTransfer.Active)
+
+	account1, err = store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(400), account1.Balance)
+}
+
+func TestStore_RunScheduledTransferTxEndDate(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
+	endDate := time.Now()
+	schedule := createDueScheduledTransfer(t, account1, account2, 100, &endDate)
+
+	result := runDueScheduledTransfer(t, store, schedule)
+	require.NotNil(t, result.Run.TransferID)
+	require.False(t, result.ScheduledTransfer.Active)
+}
+
+func TestNextRunAt(t *testing.T) {
+	start := time.Date(2024, time.January, 31, 9, 0, 0, 0, time.UTC)
+	schedule := ScheduledTransfer{StartAt: start, NextRunAt: start, IntervalUnit: IntervalWeek, IntervalCount: 2}
+
+	// runs missed while no worker was running are skipped
+	next := NextRunAt(schedule, start.AddDate(0, 0, 30))
+	require.Equal(t, start.AddDate(0, 0, 42), next)
+
+	schedule.IntervalUnit = IntervalDay
+	schedule.IntervalCount = 1
+	require.Equal(t, start.AddDate(0, 0, 1), NextRunAt(schedule, start))
+
+	// a schedule which hasn't started yet runs at its start
+	require.Equal(t, start, NextRunAt(schedule, start.Add(-time.Hour)))
+}
+
+func TestNextRunAtMonthEnd(t *testing.T) {
+	start := time.Date(2024, time.January, 31, 9, 0, 0, 0, time.UTC)
+	schedule := ScheduledTransfer{StartAt: start, NextRunAt: start, IntervalUnit: IntervalMonth, IntervalCount: 1}
+
+	// every run is on the last day of the month, a short month doesn't shift the next ones
+	want := []time.Time{
+		time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, time.March, 31, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, time.April, 30, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, time.May, 31, 9, 0, 0, 0, time.UTC),
+	}
+	now := start
+	for _, run := range want {
+		now = NextRunAt(schedule, now)
+		require.Equal(t, run, now)
+	}
+
+	schedule.IntervalCount = 12
+	require.Equal(t, time.Date(2025, time.January, 31, 9, 0, 0, 0, time.UTC), NextRunAt(schedule, start))
+
+	// a yearly run from a leap day falls on February 28 until the next leap year
+	leapDay := time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC)
+	schedule.StartAt = leapDay
+	require.Equal(t, time.Date(2025, time.February, 28, 9, 0, 0, 0, time.UTC), NextRunAt(schedule, leapDay))
+	require.Equal(t, time.Date(2028, time.February, 29, 9, 0, 0, 0, time.UTC), NextRunAt(schedule, leapDay.AddDate(3, 0, 0)))
+}
@@ -0,0 +1,115 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomSession(t *testing.T) Session {
+	user := createRandomUser(t)
+
+	arg := CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		RefreshToken: util.RandomString(32),
+		UserAgent:    util.RandomString(10),
+		ClientIp:     "127.0.0.1",
+		IsBlocked:    false,
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}
+
+	session, err := testQueries.CreateSession(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, session)
+
+	require.Equal(t, arg.ID, session.ID)
+	require.Equal(t, arg.Username, session.Username)
+	require.Equal(t, arg.RefreshToken, session.RefreshToken)
+	require.Equal(t, arg.UserAgent, session.UserAgent)
+	require.Equal(t, arg.ClientIp, session.ClientIp)
+	require.False(t, session.IsBlocked)
+	require.WithinDuration(t, arg.ExpiresAt, session.ExpiresAt, time.Second)
+	require.NotZero(t, session.CreatedAt)
+
+	return session
+}
+
+func TestQueries_CreateSession(t *testing.T) {
+	createRandomSession(t)
+}
+
+func TestQueries_GetSession(t *testing.T) {
+	session1 := createRandomSession(t)
+
+	session2, err := testQueries.GetSession(context.Background(), session1.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, session2)
+
+	require.Equal(t, session1.ID, session2.ID)
+	require.Equal(t, session1.Username, session2.Username)
+	require.Equal(t, session1.RefreshToken, session2.RefreshToken)
+	require.Equal(t, session1.IsBlocked, session2.IsBlocked)
+	require.WithinDuration(t, session1.ExpiresAt, session2.ExpiresAt, time.Second)
+	require.WithinDuration(t, session1.CreatedAt, session2.CreatedAt, time.Second)
+}
+
+func TestQueries_BlockSession(t *testing.T) {
+	session1 := createRandomSession(t)
+
+	session2, err := testQueries.BlockSession(context.Background(), session1.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, session2)
+
+	require.Equal(t, session1.ID, session2.ID)
+	require.True(t, session2.IsBlocked)
+}
+
+func TestQueries_IsSessionBlocked(t *testing.T) {
+	session := createRandomSession(t)
+
+	blocked, err := testQueries.IsSessionBlocked(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.False(t, blocked)
+
+	_, err = testQueries.BlockSession(context.Background(), session.ID)
+	require.NoError(t, err)
+
+	blocked, err = testQueries.IsSessionBlocked(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, blocked)
+}
+
+func TestQueries_DeleteExpiredSessions(t *testing.T) {
+	user := createRandomUser(t)
+	now := time.Now()
+
+	expired := CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		RefreshToken: util.RandomString(32),
+		ExpiresAt:    now.Add(-time.Minute),
+	}
+	valid := expired
+	valid.ID = uuid.New()
+	valid.ExpiresAt = now.Add(time.Minute)
+
+	_, err := testQueries.CreateSession(context.Background(), expired)
+	require.NoError(t, err)
+	_, err = testQueries.CreateSession(context.Background(), valid)
+	require.NoError(t, err)
+
+	deleted, err := testQueries.DeleteExpiredSessions(context.Background(), now)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(1))
+
+	_, err = testQueries.GetSession(context.Background(), expired.ID)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	_, err = testQueries.GetSession(context.Background(), valid.ID)
+	require.NoError(t, err)
+}
@@ -3,17 +3,30 @@ package repo
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
-)
-
-const (
-	ForeignKeyViolation = "foreign_key_violation"
-	UniqueViolation     = "unique_violation"
+	"time"
 )
 
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	ExchangeTransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	LoadRatesTx(ctx context.Context, arg []CreateRateParams) ([]Rate, error)
+	VerifyLedger(ctx context.Context) (LedgerReport, error)
+	DepositTx(ctx context.Context, arg BalanceChangeTxParams) (BalanceChangeTxResult, error)
+	WithdrawTx(ctx context.Context, arg BalanceChangeTxParams) (BalanceChangeTxResult, error)
+	AdjustBalanceTx(ctx context.Context, arg AdjustBalanceTxParams) (BalanceChangeTxResult, error)
+	ChangeAccountStatusTx(ctx context.Context, arg ChangeAccountStatusTxParams) (Account, error)
+	ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (TransferTxResult, error)
+	AuthorizeTransferTx(ctx context.Context, arg AuthorizeTransferTxParams) (PendingTransferTxResult, error)
+	CaptureTransferTx(ctx context.Context, transferID int64) (TransferTxResult, error)
+	VoidTransferTx(ctx context.Context, transferID int64) (PendingTransferTxResult, error)
+	VoidExpiredTransferTx(ctx context.Context, now time.Time) (PendingTransferTxResult, error)
+	Ping(ctx context.Context) error
+	GetMigrationStatus(ctx context.Context) (MigrationStatus, error)
+	RunScheduledTransferTx(ctx context.Context, now time.Time) (ScheduledTransferRunResult, error)
 }
 
 // SQLStore provides all functions to execute db queries and transactions
@@ -32,7 +45,24 @@ func NewStore(db *sql.DB) Store {
 	}
 }
 
+// maxDeadlockRetries bounds how many times a transaction aborted to break a deadlock is run again
+const maxDeadlockRetries = 3
+
+// execTx runs fn within a transaction. A transaction aborted by the database to break a deadlock
+// is run again from the start, so fn must not keep state between runs: a result it collects
+// piece by piece is reset at the start of fn.
 func (s *SQLStore) execTx(ctx context.Context, fn func(queries *Queries) error) error {
+	for retries := 0; ; retries++ {
+		err := s.runTx(ctx, fn)
+		if retries == maxDeadlockRetries || !isDeadlock(err) || ctx.Err() != nil {
+			return err
+		}
+
+		deadlockRetries.Inc()
+	}
+}
+
+func (s *SQLStore) runTx(ctx context.Context, fn func(queries *Queries) error) error {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -41,19 +71,42 @@ func (s *SQLStore) execTx(ctx context.Context, fn func(queries *Queries) error)
 	q := New(tx)
 	err = fn(q)
 	if err != nil {
+		dbTransactions.WithLabelValues(txRolledBack).Inc()
 		if rbErr := tx.Rollback(); rbErr != nil {
-			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+			return fmt.Errorf("tx err: %w, rb err: %v", err, rbErr)
 		}
 		return err
 	}
 
-	return tx.Commit()
+	// a failed commit is rolled back by the database
+	if err = tx.Commit(); err != nil {
+		dbTransactions.WithLabelValues(txRolledBack).Inc()
+		return err
+	}
+
+	dbTransactions.WithLabelValues(txCommitted).Inc()
+	return nil
 }
 
 type TransferTxParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID     int64           `json:"from_account_id"`
+	ToAccountID       int64           `json:"to_account_id"`
+	Amount            int64           `json:"amount"`
+	Description       string          `json:"description"`
+	ExternalReference string          `json:"external_reference"`
+	Metadata          json.RawMessage `json:"metadata"`
+	// Idempotency is optional, when it's set the result is stored under the key in the same transaction
+	Idempotency *IdempotencyParams `json:"-"`
+}
+
+// idempotencyKeyTTL is how long a result is kept for retries of the request, the sweeper deletes it later
+const idempotencyKeyTTL = 24 * time.Hour
+
+// IdempotencyParams identifies a client request that must be executed only once
+type IdempotencyParams struct {
+	Username    string
+	Key         string
+	RequestHash string
 }
 
 type TransferTxResult struct {
@@ -66,48 +119,207 @@ type TransferTxResult struct {
 
 // TransferTx creates transfer record, add account entries, and update account balance within a single database transaction
 func (s *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
+	return s.transferTx(ctx, arg, false)
+}
+
+// transferTx converts the amount with the latest rate of the currency pair when exchange is allowed,
+// otherwise accounts must be in the same currency
+func (s *SQLStore) transferTx(ctx context.Context, arg TransferTxParams, exchange bool) (TransferTxResult, error) {
 	var result TransferTxResult
+	start := time.Now()
 
 	err := s.execTx(ctx, func(q *Queries) error {
 		var err error
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
-		})
+		result, err = transfer(ctx, q, arg, exchange)
 		if err != nil {
 			return err
 		}
 
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
-		if err != nil {
-			return err
+		if arg.Idempotency != nil {
+			return storeIdempotencyKey(ctx, q, arg.Idempotency, result)
 		}
 
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
-		})
+		return nil
+	})
+
+	if err != nil {
+		transferTxDuration.WithLabelValues(txRolledBack).Observe(time.Since(start).Seconds())
+		// the balance check constraint backs up the funds check of transfer, wrapError maps it to ErrInsufficientFunds
+		return result, wrapError(err)
+	}
+
+	transferTxDuration.WithLabelValues(txCommitted).Observe(time.Since(start).Seconds())
+	observePosted(result.FromAccount.Currency, result.Transfer.Amount)
+	return result, nil
+}
+
+// transfer runs the checks and moves the money within the transaction of q
+func transfer(ctx context.Context, q *Queries, arg TransferTxParams, exchange bool) (TransferTxResult, error) {
+	params, _, _, err := prepareTransfer(ctx, q, arg, exchange)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	return postTransfer(ctx, q, params)
+}
+
+// prepareTransfer locks both accounts, runs the checks of a transfer and returns the amounts to move.
+// Held money isn't available, so the funds check uses the available balance.
+func prepareTransfer(
+	ctx context.Context,
+	q *Queries,
+	arg TransferTxParams,
+	exchange bool,
+) (params CreateTransferParams, fromAccount, toAccount Account, err error) {
+
+	// both accounts are locked in the same id order as the balance updates below to avoid deadlocks,
+	// so the source balance can't change between the funds check and the debit
+	if arg.FromAccountID < arg.ToAccountID {
+		fromAccount, toAccount, err = lockAccounts(ctx, q, arg.FromAccountID, arg.ToAccountID)
+	} else {
+		toAccount, fromAccount, err = lockAccounts(ctx, q, arg.ToAccountID, arg.FromAccountID)
+	}
+	if err != nil {
+		return
+	}
+
+	if err = requireActive(fromAccount); err != nil {
+		return
+	}
+	if err = requireActive(toAccount); err != nil {
+		return
+	}
+
+	rate, toAmount := sameCurrencyRate, arg.Amount
+	if fromAccount.Currency != toAccount.Currency {
+		if !exchange {
+			err = fmt.Errorf("%w: %s vs %s", ErrCurrencyMismatch, fromAccount.Currency, toAccount.Currency)
+			return
+		}
+
+		rate, toAmount, err = exchangeAmount(ctx, q, fromAccount.Currency, toAccount.Currency, arg.Amount)
 		if err != nil {
-			return err
+			return
 		}
+	}
 
-		// money is moving out from account which has smaller id order
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-		} else {
-			// if second account is smaller than first - we want update happens for account 2 account in first place
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+	if err = checkLimits(ctx, q, fromAccount, arg.Amount, time.Now()); err != nil {
+		return
+	}
 
-		}
+	if fromAccount.AvailableBalance+fromAccount.OverdraftLimit < arg.Amount {
+		err = ErrInsufficientFunds
+		return
+	}
 
-		return nil
+	params = CreateTransferParams{
+		FromAccountID:     arg.FromAccountID,
+		ToAccountID:       arg.ToAccountID,
+		Amount:            arg.Amount,
+		ToAmount:          toAmount,
+		Rate:              rate,
+		Description:       arg.Description,
+		ExternalReference: arg.ExternalReference,
+		Metadata:          arg.Metadata,
+	}
+	return
+}
+
+// postTransfer creates the transfer with its entries and moves the money.
+// Both accounts must be locked and checked by the caller.
+func postTransfer(ctx context.Context, q *Queries, arg CreateTransferParams) (TransferTxResult, error) {
+	arg.Metadata = metadataOrEmpty(arg.Metadata)
+
+	transfer, err := q.CreateTransfer(ctx, arg)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	return postEntries(ctx, q, transfer)
+}
+
+// postEntries records the entries of a posted transfer and moves the money.
+// The entries get the description, reference and metadata of the transfer, so statements don't need a join.
+func postEntries(ctx context.Context, q *Queries, transfer Transfer) (result TransferTxResult, err error) {
+	result.Transfer = transfer
+
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:         transfer.FromAccountID,
+		Amount:            -transfer.Amount,
+		TransferID:        &transfer.ID,
+		Kind:              EntryKindTransfer,
+		Description:       transfer.Description,
+		ExternalReference: transfer.ExternalReference,
+		Metadata:          transfer.Metadata,
+	})
+	if err != nil {
+		return
+	}
+
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:         transfer.ToAccountID,
+		Amount:            transfer.ToAmount,
+		TransferID:        &transfer.ID,
+		Kind:              EntryKindTransfer,
+		Description:       transfer.Description,
+		ExternalReference: transfer.ExternalReference,
+		Metadata:          transfer.Metadata,
+	})
+	if err != nil {
+		return
+	}
+
+	// money is moving out from account which has smaller id order
+	if transfer.FromAccountID < transfer.ToAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, transfer.FromAccountID, -transfer.Amount, transfer.ToAccountID, transfer.ToAmount)
+	} else {
+		// if second account is smaller than first - we want update happens for account 2 account in first place
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, transfer.ToAccountID, transfer.ToAmount, transfer.FromAccountID, -transfer.Amount)
+	}
+
+	return
+}
+
+// emptyMetadata is stored when no metadata is given, the columns are never null
+var emptyMetadata = json.RawMessage(`{}`)
+
+func metadataOrEmpty(metadata json.RawMessage) json.RawMessage {
+	if len(metadata) == 0 {
+		return emptyMetadata
+	}
+	return metadata
+}
+
+// storeIdempotencyKey saves the serialized result, so a retried request can be answered without executing it again
+func storeIdempotencyKey(ctx context.Context, q *Queries, arg *IdempotencyParams, result any) error {
+	response, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateIdempotencyKey(ctx, CreateIdempotencyKeyParams{
+		Username:    arg.Username,
+		Key:         arg.Key,
+		RequestHash: arg.RequestHash,
+		Response:    response,
+		ExpiresAt:   time.Now().Add(idempotencyKeyTTL),
 	})
+	// the primary key waits for a concurrent transaction with the same key and fails once it has committed
+	if err = wrapError(err); errors.Is(err, ErrUniqueViolation) {
+		return ErrIdempotencyKeyExists
+	}
+
+	return err
+}
+
+func lockAccounts(ctx context.Context, q *Queries, accountID1, accountID2 int64) (account1, account2 Account, err error) {
+	account1, err = q.GetAccountForUpdate(ctx, accountID1)
+	if err != nil {
+		return
+	}
 
-	return result, err
+	account2, err = q.GetAccountForUpdate(ctx, accountID2)
+	return
 }
 
 func addMoney(
@@ -2,16 +2,36 @@ package repo
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
+	"github.com/lib/pq"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
+// createFundedAccount creates an account in USD with enough money for every transfer of a test
+func createFundedAccount(t *testing.T, balance int64) Account {
+	user := createRandomUser(t)
+
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  balance,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+	require.Equal(t, balance, account.Balance)
+
+	return account
+}
+
 func TestStore_TransferTx(t *testing.T) {
 	store := NewStore(testDB)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
 	fmt.Println(">> balance", account1.Balance, account2.Balance)
 
 	//run n concurrent transfer transactions
@@ -117,8 +137,8 @@ func TestStore_TransferTx(t *testing.T) {
 func TestStore_TransferTxDeadLock(t *testing.T) {
 	store := NewStore(testDB)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
 	fmt.Println(">> balance", account1.Balance, account2.Balance)
 
 	//run n concurrent transfer transactions
@@ -165,3 +185,203 @@ func TestStore_TransferTxDeadLock(t *testing.T) {
 	require.Equal(t, account1.Balance, updatedAccount1.Balance)
 	require.Equal(t, account2.Balance, updatedAccount2.Balance)
 }
+
+func TestStore_TransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 10)
+	account2 := createFundedAccount(t, 10)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        11,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// nothing must be moved by the rolled back transaction
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+}
+
+func TestStore_TransferTxMetadata(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 100)
+	account2 := createFundedAccount(t, 100)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID:     account1.ID,
+		ToAccountID:       account2.ID,
+		Amount:            10,
+		Description:       "rent for March",
+		ExternalReference: "INV-42",
+		Metadata:          json.RawMessage(`{"order": "42"}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "rent for March", result.Transfer.Description)
+	require.Equal(t, "INV-42", result.Transfer.ExternalReference)
+	require.JSONEq(t, `{"order": "42"}`, string(result.Transfer.Metadata))
+
+	// both entries carry the details of the transfer
+	for _, entry := range []Entry{result.FromEntry, result.ToEntry} {
+		require.Equal(t, result.Transfer.Description, entry.Description)
+		require.Equal(t, result.Transfer.ExternalReference, entry.ExternalReference)
+		require.JSONEq(t, `{"order": "42"}`, string(entry.Metadata))
+	}
+
+	// metadata is optional
+	result, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{}`, string(result.Transfer.Metadata))
+}
+
+func TestStore_TransferTxOverdraft(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 10)
+	account2 := createFundedAccount(t, 10)
+
+	account1, err := testQueries.UpdateAccountOverdraftLimit(context.Background(), UpdateAccountOverdraftLimitParams{
+		OverdraftLimit: 5,
+		ID:             account1.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(5), account1.OverdraftLimit)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        15,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(-5), result.FromAccount.Balance)
+	require.Equal(t, int64(25), result.ToAccount.Balance)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestStore_TransferTxCurrencyMismatch(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 10)
+	account2 := createFundedAccount(t, 10)
+
+	_, err := testDB.Exec("update accounts set currency = $1 where id = $2", util.EUR, account2.ID)
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+}
+
+func TestStore_TransferTxIdempotency(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 10)
+	account2 := createFundedAccount(t, 10)
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+		Idempotency: &IdempotencyParams{
+			Username:    account1.Owner,
+			Key:         util.RandomString(32),
+			RequestHash: util.RandomString(64),
+		},
+	}
+
+	result, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	stored, err := store.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username: arg.Idempotency.Username,
+		Key:      arg.Idempotency.Key,
+	})
+	require.NoError(t, err)
+	require.Equal(t, arg.Idempotency.RequestHash, stored.RequestHash)
+
+	var storedResult TransferTxResult
+	require.NoError(t, json.Unmarshal(stored.Response, &storedResult))
+	require.Equal(t, result.Transfer.ID, storedResult.Transfer.ID)
+
+	// the retry is rolled back, so the money moves only once
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrIdempotencyKeyExists)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-arg.Amount, updatedAccount1.Balance)
+
+	// the key is kept for retries until it expires, the sweeper deletes it then
+	require.WithinDuration(t, time.Now().Add(idempotencyKeyTTL), stored.ExpiresAt, time.Minute)
+
+	_, err = store.DeleteExpiredIdempotencyKeys(context.Background(), stored.ExpiresAt)
+	require.NoError(t, err)
+
+	_, err = store.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username: arg.Idempotency.Username,
+		Key:      arg.Idempotency.Key,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestStore_GetAccountNotFound(t *testing.T) {
+	store := NewStore(testDB)
+
+	_, err := store.GetAccount(context.Background(), -1)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.EqualError(t, err, ErrRecordNotFound.Error())
+}
+
+func TestStore_CreateUserUniqueViolation(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	_, err := store.CreateUser(context.Background(), CreateUserParams{
+		Username:     user.Username,
+		FullName:     user.FullName,
+		Email:        util.RandomEmail(),
+		HashPassword: user.HashPassword,
+	})
+	require.ErrorIs(t, err, ErrUniqueViolation)
+}
+
+func TestStore_CreateAccountForeignKeyViolation(t *testing.T) {
+	store := NewStore(testDB)
+
+	_, err := store.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    util.RandomOwner(),
+		Currency: util.USD,
+	})
+	require.ErrorIs(t, err, ErrForeignKeyViolation)
+}
+
+func TestIsDeadlock(t *testing.T) {
+	deadlock := &pq.Error{Code: "40P01"}
+
+	require.True(t, isDeadlock(deadlock))
+	require.True(t, isDeadlock(fmt.Errorf("tx err: %w, rb err: %v", deadlock, sql.ErrTxDone)))
+	require.False(t, isDeadlock(&pq.Error{Code: "23514"}))
+	require.False(t, isDeadlock(sql.ErrConnDone))
+	require.False(t, isDeadlock(nil))
+}
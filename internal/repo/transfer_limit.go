@@ -0,0 +1,78 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// limits checked for every outgoing transfer and withdrawal, reported by LimitExceededError
+const (
+	LimitMaxAmount      = "max_amount"
+	LimitAccountDaily   = "account_daily_limit"
+	LimitAccountMonthly = "account_monthly_limit"
+	LimitUserDaily      = "user_daily_limit"
+	LimitUserMonthly    = "user_monthly_limit"
+)
+
+// LimitExceededError is returned when a transfer would exceed one of the limits of the sender
+type LimitExceededError struct {
+	Limit string `json:"limit"`
+	Max   int64  `json:"max"`
+	// Used is the amount already sent in the period of the limit, zero for the max amount
+	Used int64 `json:"used"`
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%s: %s is %d, %d already used", ErrLimitExceeded, e.Limit, e.Max, e.Used)
+}
+
+func (e *LimitExceededError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// checkLimits makes sure the amount fits the limits of the account owner in the currency of the account.
+// Periods are UTC days and months. The account must be locked by the caller: an owner has a single open account
+// per currency, so the lock serializes the checks of the user totals too.
+func checkLimits(ctx context.Context, q *Queries, account Account, amount int64, now time.Time) error {
+	limit, err := q.GetEffectiveTransferLimit(ctx, GetEffectiveTransferLimitParams{
+		Username: account.Owner,
+		Currency: account.Currency,
+	})
+	if err != nil {
+		return err
+	}
+
+	if limit.MaxAmount > 0 && amount > limit.MaxAmount {
+		return &LimitExceededError{Limit: LimitMaxAmount, Max: limit.MaxAmount}
+	}
+	if limit.AccountDailyLimit == 0 && limit.AccountMonthlyLimit == 0 && limit.UserDailyLimit == 0 && limit.UserMonthlyLimit == 0 {
+		return nil
+	}
+
+	now = now.UTC()
+	totals, err := q.GetOutgoingTotals(ctx, GetOutgoingTotalsParams{
+		AccountID:  account.ID,
+		DayStart:   time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		Owner:      account.Owner,
+		Currency:   account.Currency,
+		MonthStart: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		return err
+	}
+
+	periods := []LimitExceededError{
+		{Limit: LimitAccountDaily, Max: limit.AccountDailyLimit, Used: totals.AccountDaily},
+		{Limit: LimitAccountMonthly, Max: limit.AccountMonthlyLimit, Used: totals.AccountMonthly},
+		{Limit: LimitUserDaily, Max: limit.UserDailyLimit, Used: totals.UserDaily},
+		{Limit: LimitUserMonthly, Max: limit.UserMonthlyLimit, Used: totals.UserMonthly},
+	}
+	for i := range periods {
+		if periods[i].Max > 0 && periods[i].Used+amount > periods[i].Max {
+			return &periods[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/max-rodziyevsky/go-simple-bank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestStore_TransferTxLimits(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 10000)
+	account2 := createFundedAccount(t, 10000)
+
+	// the override only applies to the owner of account1, so other tests keep the defaults
+	_, err := testQueries.UpsertUserTransferLimit(context.Background(), UpsertUserTransferLimitParams{
+		Username:          account1.Owner,
+		Currency:          account1.Currency,
+		MaxAmount:         500,
+		AccountDailyLimit: 800,
+	})
+	require.NoError(t, err)
+
+	transfer := func(amount int64) error {
+		_, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+		})
+		return err
+	}
+
+	var limitErr *LimitExceededError
+	err = transfer(501)
+	require.ErrorIs(t, err, ErrLimitExceeded)
+	require.True(t, errors.As(err, &limitErr))
+	require.Equal(t, LimitExceededError{Limit: LimitMaxAmount, Max: 500}, *limitErr)
+
+	require.NoError(t, transfer(500))
+	require.NoError(t, transfer(300))
+
+	err = transfer(1)
+	require.True(t, errors.As(err, &limitErr))
+	require.Equal(t, LimitExceededError{Limit: LimitAccountDaily, Max: 800, Used: 800}, *limitErr)
+
+	// incoming transfers don't count
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        1000,
+	})
+	require.NoError(t, err)
+
+	err = testQueries.DeleteUserTransferLimit(context.Background(), DeleteUserTransferLimitParams{
+		Username: account1.Owner,
+		Currency: account1.Currency,
+	})
+	require.NoError(t, err)
+	require.NoError(t, transfer(1))
+}
+
+func TestStore_WithdrawTxLimits(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 10000)
+	account2 := createFundedAccount(t, 10000)
+
+	_, err := testQueries.UpsertUserTransferLimit(context.Background(), UpsertUserTransferLimitParams{
+		Username:          account1.Owner,
+		Currency:          account1.Currency,
+		AccountDailyLimit: 800,
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        500,
+	})
+	require.NoError(t, err)
+
+	withdraw := func(amount int64) error {
+		_, err := store.WithdrawTx(context.Background(), BalanceChangeTxParams{
+			AccountID: account1.ID,
+			Amount:    amount,
+		})
+		return err
+	}
+
+	// withdrawals and transfers share the same totals
+	require.NoError(t, withdraw(200))
+
+	var limitErr *LimitExceededError
+	err = withdraw(101)
+	require.True(t, errors.As(err, &limitErr))
+	require.Equal(t, LimitExceededError{Limit: LimitAccountDaily, Max: 800, Used: 700}, *limitErr)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        101,
+	})
+	require.True(t, errors.As(err, &limitErr))
+	require.Equal(t, LimitExceededError{Limit: LimitAccountDaily, Max: 800, Used: 700}, *limitErr)
+
+	// deposits don't count
+	_, err = store.DepositTx(context.Background(), BalanceChangeTxParams{
+		AccountID: account1.ID,
+		Amount:    1000,
+	})
+	require.NoError(t, err)
+	require.NoError(t, withdraw(100))
+}
+
+func TestQueries_GetEffectiveTransferLimit(t *testing.T) {
+	user := createRandomUser(t)
+	arg := GetEffectiveTransferLimitParams{Username: user.Username, Currency: util.EUR}
+
+	limit, err := testQueries.GetEffectiveTransferLimit(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, limit.Overridden)
+
+	override, err := testQueries.UpsertUserTransferLimit(context.Background(), UpsertUserTransferLimitParams{
+		Username:         user.Username,
+		Currency:         util.EUR,
+		UserDailyLimit:   1000,
+		UserMonthlyLimit: 20000,
+	})
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), override.UpdatedAt, time.Minute)
+
+	limit, err = testQueries.GetEffectiveTransferLimit(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, limit.Overridden)
+	require.Equal(t, int64(1000), limit.UserDailyLimit)
+	require.Equal(t, int64(20000), limit.UserMonthlyLimit)
+	require.Zero(t, limit.MaxAmount)
+
+	limits, err := testQueries.ListEffectiveTransferLimits(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.NotEmpty(t, limits)
+	for _, l := range limits {
+		require.Equal(t, l.Currency == util.EUR, l.Overridden)
+	}
+}
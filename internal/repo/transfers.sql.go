@@ -7,22 +7,91 @@ package repo
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 )
 
+const createPendingTransfer = `-- name: CreatePendingTransfer :one
+insert into transfers(
+    from_account_id, to_account_id, amount, to_amount, rate, description, external_reference, metadata, status, expires_at
+) values ($1, $2, $3, $4, $5, $6, $7, $8, 'pending', $9)
+returning id, from_account_id, to_account_id, amount, created_at, to_amount, rate, reversal_of, description, external_reference, metadata, status, expires_at
+`
+
+type CreatePendingTransferParams struct {
+	FromAccountID     int64           `json:"from_account_id"`
+	ToAccountID       int64           `json:"to_account_id"`
+	Amount            int64           `json:"amount"`
+	ToAmount          int64           `json:"to_amount"`
+	Rate              string          `json:"rate"`
+	Description       string          `json:"description"`
+	ExternalReference string          `json:"external_reference"`
+	Metadata          json.RawMessage `json:"metadata"`
+	ExpiresAt         *time.Time      `json:"expires_at"`
+}
+
+func (q *Queries) CreatePendingTransfer(ctx context.Context, arg CreatePendingTransferParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, createPendingTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ToAmount,
+		arg.Rate,
+		arg.Description,
+		arg.ExternalReference,
+		arg.Metadata,
+		arg.ExpiresAt,
+	)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.Rate,
+		&i.ReversalOf,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
+		&i.Status,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
 const createTransfer = `-- name: CreateTransfer :one
-insert into transfers(from_account_id, to_account_id, amount)
-values ($1, $2, $3)
-returning id, from_account_id, to_account_id, amount, created_at
+insert into transfers(
+    from_account_id, to_account_id, amount, to_amount, rate, reversal_of, description, external_reference, metadata
+) values ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+returning id, from_account_id, to_account_id, amount, created_at, to_amount, rate, reversal_of, description, external_reference, metadata, status, expires_at
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID     int64           `json:"from_account_id"`
+	ToAccountID       int64           `json:"to_account_id"`
+	Amount            int64           `json:"amount"`
+	ToAmount          int64           `json:"to_amount"`
+	Rate              string          `json:"rate"`
+	ReversalOf        *int64          `json:"reversal_of"`
+	Description       string          `json:"description"`
+	ExternalReference string          `json:"external_reference"`
+	Metadata          json.RawMessage `json:"metadata"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ToAmount,
+		arg.Rate,
+		arg.ReversalOf,
+		arg.Description,
+		arg.ExternalReference,
+		arg.Metadata,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -30,12 +99,69 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.Rate,
+		&i.ReversalOf,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
+		&i.Status,
+		&i.ExpiresAt,
 	)
 	return i, err
 }
 
+const getExpiredPendingTransfer = `-- name: GetExpiredPendingTransfer :one
+select id, from_account_id, to_account_id, amount, created_at, to_amount, rate, reversal_of, description, external_reference, metadata, status, expires_at
+from transfers
+where status = 'pending'
+    and expires_at <= $1::timestamptz
+order by expires_at
+limit 1
+for no key update skip locked
+`
+
+func (q *Queries) GetExpiredPendingTransfer(ctx context.Context, now time.Time) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getExpiredPendingTransfer, now)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.Rate,
+		&i.ReversalOf,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
+		&i.Status,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const getReversedAmounts = `-- name: GetReversedAmounts :one
+select coalesce(sum(amount), 0)::bigint as amount, coalesce(sum(to_amount), 0)::bigint as to_amount
+from transfers
+where reversal_of = $1
+`
+
+type GetReversedAmountsRow struct {
+	Amount   int64 `json:"amount"`
+	ToAmount int64 `json:"to_amount"`
+}
+
+func (q *Queries) GetReversedAmounts(ctx context.Context, reversalOf *int64) (GetReversedAmountsRow, error) {
+	row := q.db.QueryRowContext(ctx, getReversedAmounts, reversalOf)
+	var i GetReversedAmountsRow
+	err := row.Scan(&i.Amount, &i.ToAmount)
+	return i, err
+}
+
 const getTransfer = `-- name: GetTransfer :one
-select id, from_account_id, to_account_id, amount, created_at
+select id, from_account_id, to_account_id, amount, created_at, to_amount, rate, reversal_of, description, external_reference, metadata, status, expires_at
 from transfers
 where id = $1
 limit 1
@@ -50,30 +176,97 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.Rate,
+		&i.ReversalOf,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
+		&i.Status,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const getTransferForUpdate = `-- name: GetTransferForUpdate :one
+select id, from_account_id, to_account_id, amount, created_at, to_amount, rate, reversal_of, description, external_reference, metadata, status, expires_at
+from transfers
+where id = $1
+limit 1
+for no key update
+`
+
+func (q *Queries) GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getTransferForUpdate, id)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.Rate,
+		&i.ReversalOf,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
+		&i.Status,
+		&i.ExpiresAt,
 	)
 	return i, err
 }
 
 const listTransfers = `-- name: ListTransfers :many
-select id, from_account_id, to_account_id, amount, created_at from transfers
-where from_account_id = $1 OR to_account_id = $2
-limit $3
-offset $4
+select id, from_account_id, to_account_id, amount, created_at, to_amount, rate, reversal_of, description, external_reference, metadata, status, expires_at from transfers
+where (
+        ($1::boolean and from_account_id = $2)
+        or ($3::boolean and to_account_id = $2)
+    )
+    and created_at >= $4
+    and created_at < $5
+    -- amounts are compared in the currency of the account: incoming transfers credited it with to_amount
+    and (case when to_account_id = $2 then to_amount else amount end)
+        between $6::bigint and $7::bigint
+    and strpos(lower(description), lower($8::varchar)) > 0
+    and ($9::varchar = '' or external_reference = $9)
+    and metadata @> $10::jsonb
+    and (created_at, id) > ($11::timestamptz, $12::bigint)
+order by created_at, id
+limit $13
 `
 
 type ListTransfersParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Limit         int32 `json:"limit"`
-	Offset        int32 `json:"offset"`
+	Outgoing          bool            `json:"outgoing"`
+	AccountID         int64           `json:"account_id"`
+	Incoming          bool            `json:"incoming"`
+	FromTime          time.Time       `json:"from_time"`
+	ToTime            time.Time       `json:"to_time"`
+	MinAmount         int64           `json:"min_amount"`
+	MaxAmount         int64           `json:"max_amount"`
+	Description       string          `json:"description"`
+	ExternalReference string          `json:"external_reference"`
+	Metadata          json.RawMessage `json:"metadata"`
+	AfterCreatedAt    time.Time       `json:"after_created_at"`
+	AfterID           int64           `json:"after_id"`
+	PageSize          int32           `json:"page_size"`
 }
 
 func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
 	rows, err := q.db.QueryContext(ctx, listTransfers,
-		arg.FromAccountID,
-		arg.ToAccountID,
-		arg.Limit,
-		arg.Offset,
+		arg.Outgoing,
+		arg.AccountID,
+		arg.Incoming,
+		arg.FromTime,
+		arg.ToTime,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.Description,
+		arg.ExternalReference,
+		arg.Metadata,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.PageSize,
 	)
 	if err != nil {
 		return nil, err
@@ -88,6 +281,14 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ToAmount,
+			&i.Rate,
+			&i.ReversalOf,
+			&i.Description,
+			&i.ExternalReference,
+			&i.Metadata,
+			&i.Status,
+			&i.ExpiresAt,
 		); err != nil {
 			return nil, err
 		}
@@ -101,3 +302,36 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 	}
 	return items, nil
 }
+
+const updateTransferStatus = `-- name: UpdateTransferStatus :one
+update transfers
+set status = $1
+where id = $2
+returning id, from_account_id, to_account_id, amount, created_at, to_amount, rate, reversal_of, description, external_reference, metadata, status, expires_at
+`
+
+type UpdateTransferStatusParams struct {
+	Status string `json:"status"`
+	ID     int64  `json:"id"`
+}
+
+func (q *Queries) UpdateTransferStatus(ctx context.Context, arg UpdateTransferStatusParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, updateTransferStatus, arg.Status, arg.ID)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.Rate,
+		&i.ReversalOf,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
+		&i.Status,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
@@ -2,6 +2,8 @@ package repo
 
 import (
 	"context"
+	"encoding/json"
+	"math"
 	"github.com/max-rodziyevsky/go-simple-bank/util"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -12,10 +14,14 @@ func createRandomTransfer(t *testing.T) Transfer {
 	account1 := createRandomAccount(t)
 	account2 := createRandomAccount(t)
 
+	amount := util.RandomMoney()
 	arg := CreateTransferParams{
 		FromAccountID: account1.ID,
 		ToAccountID:   account2.ID,
-		Amount:        util.RandomMoney(),
+		Amount:        amount,
+		ToAmount:      amount,
+		Rate:          "1",
+		Metadata:      emptyMetadata,
 	}
 
 	transfer, err := testQueries.CreateTransfer(context.Background(), arg)
@@ -25,6 +31,7 @@ func createRandomTransfer(t *testing.T) Transfer {
 	require.Equal(t, arg.FromAccountID, transfer.FromAccountID)
 	require.Equal(t, arg.ToAccountID, transfer.ToAccountID)
 	require.Equal(t, arg.Amount, transfer.Amount)
+	require.Equal(t, arg.ToAmount, transfer.ToAmount)
 
 	return transfer
 }
@@ -50,27 +57,128 @@ func TestQueries_GetTransfer(t *testing.T) {
 func TestQueries_ListTransfers(t *testing.T) {
 	transfer := createRandomTransfer(t)
 
+	var tagged Transfer
 	for i := 0; i < 10; i++ {
-		_, err := testQueries.CreateTransfer(context.Background(), CreateTransferParams{
+		amount := util.RandomMoney()
+		arg := CreateTransferParams{
 			FromAccountID: transfer.FromAccountID,
 			ToAccountID:   transfer.ToAccountID,
-			Amount:        util.RandomMoney(),
-		})
+			Amount:        amount,
+			ToAmount:      amount,
+			Rate:          "1",
+			Metadata:      emptyMetadata,
+		}
+		if i == 3 {
+			arg.Description = "Rent for March"
+			arg.ExternalReference = "INV-42"
+			arg.Metadata = json.RawMessage(`{"order": "42", "channel": "web"}`)
+		}
+
+		created, err := testQueries.CreateTransfer(context.Background(), arg)
 		require.NoError(t, err)
+		if i == 3 {
+			tagged = created
+		}
 	}
 
 	arg := ListTransfersParams{
-		FromAccountID: transfer.FromAccountID,
-		ToAccountID:   transfer.ToAccountID,
-		Limit:         5,
-		Offset:        5,
+		Outgoing:  true,
+		AccountID: transfer.FromAccountID,
+		FromTime:  transfer.CreatedAt,
+		ToTime:    time.Now().Add(time.Minute),
+		MinAmount: 0,
+		MaxAmount: math.MaxInt64,
+		Metadata:  emptyMetadata,
+		PageSize:  5,
+	}
+
+	page1, err := testQueries.ListTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, page1, 5)
+	require.Equal(t, transfer.ID, page1[0].ID)
+
+	// the next page starts right after the last transfer of the previous one
+	last := page1[len(page1)-1]
+	arg.AfterCreatedAt = last.CreatedAt
+	arg.AfterID = last.ID
+
+	page2, err := testQueries.ListTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, page2, 5)
+
+	for _, t2 := range append(page1, page2...) {
+		require.Equal(t, transfer.FromAccountID, t2.FromAccountID)
 	}
+	require.True(t, page2[0].CreatedAt.After(last.CreatedAt) || page2[0].ID > last.ID)
+
+	// the source account has no incoming transfers
+	arg.Outgoing, arg.Incoming = false, true
+	arg.AfterCreatedAt, arg.AfterID = time.Time{}, 0
 
 	transfers, err := testQueries.ListTransfers(context.Background(), arg)
 	require.NoError(t, err)
-	require.Len(t, transfers, 5)
+	require.Empty(t, transfers)
+
+	// search by a part of the description, the reference and a metadata pair
+	arg.Outgoing, arg.Incoming = true, false
+	arg.Description = "rent"
+	arg.ExternalReference = "INV-42"
+	arg.Metadata = json.RawMessage(`{"order": "42"}`)
+
+	transfers, err = testQueries.ListTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	require.Equal(t, tagged.ID, transfers[0].ID)
+	require.JSONEq(t, `{"order": "42", "channel": "web"}`, string(transfers[0].Metadata))
 
-	for _, t2 := range transfers {
-		require.NotEmpty(t, t2)
+	arg.Metadata = json.RawMessage(`{"order": "43"}`)
+	transfers, err = testQueries.ListTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+}
+
+func TestQueries_ListTransfersAmountInAccountCurrency(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	// 100 in the source currency credit 9000 in the destination currency
+	transfer, err := testQueries.CreateTransfer(context.Background(), CreateTransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+		ToAmount:      9000,
+		Rate:          "90",
+		Metadata:      emptyMetadata,
+	})
+	require.NoError(t, err)
+
+	arg := ListTransfersParams{
+		Incoming:  true,
+		AccountID: account2.ID,
+		FromTime:  transfer.CreatedAt,
+		ToTime:    time.Now().Add(time.Minute),
+		MinAmount: 5000,
+		MaxAmount: 10000,
+		Metadata:  emptyMetadata,
+		PageSize:  5,
 	}
-}
\ No newline at end of file
+
+	// the destination account received the converted amount
+	transfers, err := testQueries.ListTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	require.Equal(t, transfer.ID, transfers[0].ID)
+
+	arg.MinAmount, arg.MaxAmount = 50, 200
+	transfers, err = testQueries.ListTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+
+	// the source account sent the amount in its own currency
+	arg.Incoming, arg.Outgoing = false, true
+	arg.AccountID = account1.ID
+	transfers, err = testQueries.ListTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	require.Equal(t, transfer.ID, transfers[0].ID)
+}
@@ -12,7 +12,7 @@ import (
 const createUser = `-- name: CreateUser :one
 insert into users (username, full_name, email, hash_password)
 values ($1, $2, $3, $4)
-returning username, full_name, email, hash_password, change_password_at, created_at
+returning username, full_name, email, hash_password, change_password_at, created_at, role
 `
 
 type CreateUserParams struct {
@@ -37,12 +37,13 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.HashPassword,
 		&i.ChangePasswordAt,
 		&i.CreatedAt,
+		&i.Role,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-select username, full_name, email, hash_password, change_password_at, created_at from  users
+select username, full_name, email, hash_password, change_password_at, created_at, role from  users
 where username = $1
 limit 1
 `
@@ -57,6 +58,7 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.HashPassword,
 		&i.ChangePasswordAt,
 		&i.CreatedAt,
+		&i.Role,
 	)
 	return i, err
 }
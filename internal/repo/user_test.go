@@ -51,6 +51,7 @@ func createRandomUser(t *testing.T) User {
 	// when user created user must zero value on change password at time
 	require.True(t, user.ChangePasswordAt.IsZero())
 	require.NotZero(t, user.CreatedAt)
+	require.Equal(t, util.DepositorRole, user.Role)
 
 	return user
 }
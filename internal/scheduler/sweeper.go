@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"log"
+	"time"
+)
+
+// Releaser voids pending transfers whose hold has expired
+type Releaser interface {
+	VoidExpiredTransferTx(ctx context.Context, now time.Time) (repo.PendingTransferTxResult, error)
+}
+
+// Cleaner deletes the rows which aren't needed anymore once they have expired
+type Cleaner interface {
+	DeleteExpiredIdempotencyKeys(ctx context.Context, expiresAt time.Time) (int64, error)
+	DeleteExpiredRevokedTokens(ctx context.Context, expiresAt time.Time) (int64, error)
+	DeleteExpiredSessions(ctx context.Context, expiresAt time.Time) (int64, error)
+}
+
+// expiredSessionRetention keeps expired sessions for a while. An access token renewed right before
+// its session expired outlives it, and blocking the session must still reject the token until it expires.
+const expiredSessionRetention = 24 * time.Hour
+
+// Sweeper releases expired holds and deletes expired idempotency keys, revoked tokens and sessions every interval.
+// Like workers, it runs on every server instance, the releaser skips transfers which are being voided by another one.
+type Sweeper struct {
+	releaser Releaser
+	cleaner  Cleaner
+	interval time.Duration
+	now      func() time.Time
+}
+
+func NewSweeper(releaser Releaser, cleaner Cleaner, interval time.Duration) *Sweeper {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	return &Sweeper{
+		releaser: releaser,
+		cleaner:  cleaner,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run blocks until ctx is done
+func (s *Sweeper) Run(ctx context.Context) {
+	runEvery(ctx, s.interval, s.tick)
+}
+
+// tick deletes the rows expired by now and voids the expired transfers, one transaction per transfer.
+// It returns the number of voided transfers.
+func (s *Sweeper) tick(ctx context.Context) int {
+	now := s.now()
+	s.clean(ctx, now)
+
+	for voided := 0; voided < maxRunsPerTick; voided++ {
+		if ctx.Err() != nil {
+			return voided
+		}
+
+		_, err := s.releaser.VoidExpiredTransferTx(ctx, now)
+		if errors.Is(err, repo.ErrRecordNotFound) {
+			return voided
+		}
+		if err != nil {
+			log.Print("can't release an expired hold: ", err)
+			return voided
+		}
+	}
+
+	return maxRunsPerTick
+}
+
+// clean deletes the idempotency keys and revoked tokens expired by now and the sessions expired
+// before the retention. A failed delete is only logged, the rows are deleted on the next tick.
+func (s *Sweeper) clean(ctx context.Context, now time.Time) {
+	if _, err := s.cleaner.DeleteExpiredIdempotencyKeys(ctx, now); err != nil {
+		log.Print("can't delete expired idempotency keys: ", err)
+	}
+	if _, err := s.cleaner.DeleteExpiredRevokedTokens(ctx, now); err != nil {
+		log.Print("can't delete expired revoked tokens: ", err)
+	}
+	if _, err := s.cleaner.DeleteExpiredSessions(ctx, now.Add(-expiredSessionRetention)); err != nil {
+		log.Print("can't delete expired sessions: ", err)
+	}
+}
@@ -0,0 +1,106 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// fakeReleaser has a number of expired holds, after them it reports that nothing has expired
+type fakeReleaser struct {
+	expired int
+	err     error
+	calls   int
+}
+
+func (r *fakeReleaser) VoidExpiredTransferTx(_ context.Context, _ time.Time) (repo.PendingTransferTxResult, error) {
+	r.calls++
+	if r.err != nil {
+		return repo.PendingTransferTxResult{}, r.err
+	}
+	if r.expired == 0 {
+		return repo.PendingTransferTxResult{}, repo.ErrRecordNotFound
+	}
+
+	r.expired--
+	return repo.PendingTransferTxResult{}, nil
+}
+
+// fakeCleaner records until when the rows were deleted
+type fakeCleaner struct {
+	err               error
+	idempotencyKeysAt time.Time
+	revokedTokensAt   time.Time
+	sessionsAt        time.Time
+}
+
+func (c *fakeCleaner) DeleteExpiredIdempotencyKeys(_ context.Context, expiresAt time.Time) (int64, error) {
+	c.idempotencyKeysAt = expiresAt
+	return 0, c.err
+}
+
+func (c *fakeCleaner) DeleteExpiredRevokedTokens(_ context.Context, expiresAt time.Time) (int64, error) {
+	c.revokedTokensAt = expiresAt
+	return 0, c.err
+}
+
+func (c *fakeCleaner) DeleteExpiredSessions(_ context.Context, expiresAt time.Time) (int64, error) {
+	c.sessionsAt = expiresAt
+	return 0, c.err
+}
+
+func TestSweeper_Tick(t *testing.T) {
+	releaser := &fakeReleaser{expired: 2}
+	sweeper := NewSweeper(releaser, &fakeCleaner{}, time.Minute)
+
+	require.Equal(t, 2, sweeper.tick(context.Background()))
+	require.Equal(t, 3, releaser.calls)
+
+	// nothing has expired anymore
+	require.Equal(t, 0, sweeper.tick(context.Background()))
+}
+
+func TestSweeper_TickStopsOnError(t *testing.T) {
+	releaser := &fakeReleaser{expired: 2, err: sql.ErrConnDone}
+	sweeper := NewSweeper(releaser, &fakeCleaner{}, time.Minute)
+
+	require.Equal(t, 0, sweeper.tick(context.Background()))
+	require.Equal(t, 1, releaser.calls)
+}
+
+func TestSweeper_TickCleans(t *testing.T) {
+	now := time.Now()
+	cleaner := &fakeCleaner{}
+	sweeper := NewSweeper(&fakeReleaser{}, cleaner, time.Minute)
+	sweeper.now = func() time.Time { return now }
+
+	sweeper.tick(context.Background())
+	require.Equal(t, now, cleaner.idempotencyKeysAt)
+	require.Equal(t, now, cleaner.revokedTokensAt)
+	// sessions are kept until the access tokens renewed with them have expired
+	require.Equal(t, now.Add(-expiredSessionRetention), cleaner.sessionsAt)
+}
+
+func TestSweeper_TickReleasesWhenCleaningFails(t *testing.T) {
+	releaser := &fakeReleaser{expired: 1}
+	cleaner := &fakeCleaner{err: sql.ErrConnDone}
+	sweeper := NewSweeper(releaser, cleaner, time.Minute)
+
+	require.Equal(t, 1, sweeper.tick(context.Background()))
+	require.False(t, cleaner.revokedTokensAt.IsZero())
+	require.False(t, cleaner.sessionsAt.IsZero())
+}
+
+func TestSweeper_Run(t *testing.T) {
+	releaser := &fakeReleaser{expired: 1}
+	sweeper := NewSweeper(releaser, &fakeCleaner{}, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	sweeper.Run(ctx)
+	require.Zero(t, releaser.expired)
+}
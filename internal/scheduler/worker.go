@@ -0,0 +1,86 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"log"
+	"time"
+)
+
+const (
+	// maxRunsPerTick bounds the work of a tick, so a backlog doesn't keep a worker or a sweeper from checking ctx
+	maxRunsPerTick = 100
+	// defaultInterval is used when the interval isn't configured
+	defaultInterval = time.Minute
+)
+
+// Runner executes due scheduled transfers
+type Runner interface {
+	RunScheduledTransferTx(ctx context.Context, now time.Time) (repo.ScheduledTransferRunResult, error)
+}
+
+// Worker runs due scheduled transfers every interval. Every server instance runs a worker,
+// the runner makes sure a scheduled transfer is picked by one of them only.
+type Worker struct {
+	runner   Runner
+	interval time.Duration
+	now      func() time.Time
+}
+
+func NewWorker(runner Runner, interval time.Duration) *Worker {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	return &Worker{
+		runner:   runner,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run blocks until ctx is done
+func (w *Worker) Run(ctx context.Context) {
+	runEvery(ctx, w.interval, w.tick)
+}
+
+// runEvery calls tick every interval until ctx is done
+func runEvery(ctx context.Context, interval time.Duration, tick func(ctx context.Context) int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			tick(ctx)
+		}
+	}
+}
+
+// tick runs the transfers due now, one transaction per transfer
+func (w *Worker) tick(ctx context.Context) int {
+	now := w.now()
+	for runs := 0; runs < maxRunsPerTick; runs++ {
+		if ctx.Err() != nil {
+			return runs
+		}
+
+		result, err := w.runner.RunScheduledTransferTx(ctx, now)
+		if errors.Is(err, repo.ErrRecordNotFound) {
+			return runs
+		}
+		if err != nil {
+			log.Print("can't run a scheduled transfer: ", err)
+			return runs
+		}
+
+		if result.Run.Error != "" {
+			log.Printf("scheduled transfer %d failed: %s", result.ScheduledTransfer.ID, result.Run.Error)
+		}
+	}
+
+	return maxRunsPerTick
+}
@@ -0,0 +1,68 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"github.com/max-rodziyevsky/go-simple-bank/internal/repo"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// fakeRunner has a number of due transfers, after them it reports that nothing is due
+type fakeRunner struct {
+	due   int
+	err   error
+	calls int
+}
+
+func (r *fakeRunner) RunScheduledTransferTx(_ context.Context, _ time.Time) (repo.ScheduledTransferRunResult, error) {
+	r.calls++
+	if r.err != nil {
+		return repo.ScheduledTransferRunResult{}, r.err
+	}
+	if r.due == 0 {
+		return repo.ScheduledTransferRunResult{}, repo.ErrRecordNotFound
+	}
+
+	r.due--
+	return repo.ScheduledTransferRunResult{}, nil
+}
+
+func TestWorker_Tick(t *testing.T) {
+	runner := &fakeRunner{due: 3}
+	worker := NewWorker(runner, time.Minute)
+
+	require.Equal(t, 3, worker.tick(context.Background()))
+	require.Equal(t, 4, runner.calls)
+
+	// nothing is due anymore
+	require.Equal(t, 0, worker.tick(context.Background()))
+}
+
+func TestWorker_TickStopsOnError(t *testing.T) {
+	runner := &fakeRunner{due: 3, err: sql.ErrConnDone}
+	worker := NewWorker(runner, time.Minute)
+
+	require.Equal(t, 0, worker.tick(context.Background()))
+	require.Equal(t, 1, runner.calls)
+}
+
+func TestWorker_TickBacklog(t *testing.T) {
+	runner := &fakeRunner{due: maxRunsPerTick + 1}
+	worker := NewWorker(runner, time.Minute)
+
+	require.Equal(t, maxRunsPerTick, worker.tick(context.Background()))
+	require.Equal(t, 1, runner.due)
+}
+
+func TestWorker_Run(t *testing.T) {
+	runner := &fakeRunner{due: 1}
+	worker := NewWorker(runner, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	worker.Run(ctx)
+	require.Zero(t, runner.due)
+}
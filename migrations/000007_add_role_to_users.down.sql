@@ -0,0 +1 @@
+alter table if exists "users" drop column if exists "role";
@@ -0,0 +1,5 @@
+alter table if exists "accounts" drop constraint if exists "balance_within_overdraft_limit";
+
+alter table if exists "accounts" drop constraint if exists "overdraft_limit_non_negative";
+
+alter table if exists "accounts" drop column if exists "overdraft_limit";
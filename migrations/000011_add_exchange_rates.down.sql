@@ -0,0 +1,5 @@
+alter table if exists "transfers" drop column if exists "rate";
+
+alter table if exists "transfers" drop column if exists "to_amount";
+
+drop table if exists "rates";
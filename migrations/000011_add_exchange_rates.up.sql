@@ -0,0 +1,26 @@
+CREATE TABLE "rates" (
+    "id" bigserial PRIMARY KEY,
+    "base_currency" varchar NOT NULL,
+    "quote_currency" varchar NOT NULL,
+    "rate" numeric(20, 10) NOT NULL,
+    "valid_from" timestamptz NOT NULL DEFAULT (now()),
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "rates" ("base_currency", "quote_currency", "valid_from");
+
+ALTER TABLE "rates" ADD CONSTRAINT "rate_positive" CHECK ("rate" > 0);
+
+COMMENT ON COLUMN "rates"."rate" IS 'amount of quote currency for one unit of base currency';
+
+ALTER TABLE "transfers" ADD COLUMN "to_amount" bigint;
+
+UPDATE "transfers" SET "to_amount" = "amount";
+
+ALTER TABLE "transfers" ALTER COLUMN "to_amount" SET NOT NULL;
+
+ALTER TABLE "transfers" ADD COLUMN "rate" numeric(20, 10) NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN "transfers"."to_amount" IS 'credited to the destination account in its currency';
+
+COMMENT ON COLUMN "transfers"."rate" IS 'exchange rate applied to the amount, 1 for transfers in the same currency';
@@ -0,0 +1,7 @@
+ALTER TABLE "rates" DROP CONSTRAINT rates_quote_currency_fkey;
+
+ALTER TABLE "rates" DROP CONSTRAINT rates_base_currency_fkey;
+
+ALTER TABLE "accounts" DROP CONSTRAINT accounts_currency_fkey;
+
+drop table if exists "currencies";
@@ -0,0 +1,20 @@
+CREATE TABLE "currencies" (
+    "code" varchar(3) PRIMARY KEY,
+    "exponent" integer NOT NULL DEFAULT 2,
+    "enabled" boolean NOT NULL DEFAULT true,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "currencies" ADD CONSTRAINT "exponent_range" CHECK ("exponent" BETWEEN 0 AND 4);
+
+COMMENT ON COLUMN "currencies"."code" IS 'ISO 4217 alphabetic code';
+
+COMMENT ON COLUMN "currencies"."exponent" IS 'number of digits after the decimal separator, amounts are stored in minor units';
+
+INSERT INTO "currencies" ("code", "exponent") VALUES ('USD', 2), ('EUR', 2), ('UAH', 2);
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+
+ALTER TABLE "rates" ADD FOREIGN KEY ("base_currency") REFERENCES "currencies" ("code");
+
+ALTER TABLE "rates" ADD FOREIGN KEY ("quote_currency") REFERENCES "currencies" ("code");
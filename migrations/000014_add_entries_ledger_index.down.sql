@@ -0,0 +1 @@
+DROP INDEX IF EXISTS entries_account_id_created_at_id_idx;
@@ -0,0 +1 @@
+alter table if exists "entries" drop column if exists "transfer_id";
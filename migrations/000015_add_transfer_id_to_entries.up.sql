@@ -0,0 +1,17 @@
+ALTER TABLE "entries" ADD COLUMN "transfer_id" bigint;
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "entries" ("transfer_id");
+
+COMMENT ON COLUMN "entries"."transfer_id" IS 'transfer which created the entry, empty for entries not caused by a transfer';
+
+-- entries of a transfer are created in the same transaction, so they share its creation time
+UPDATE "entries" AS e
+SET "transfer_id" = t."id"
+FROM "transfers" AS t
+WHERE e."created_at" = t."created_at"
+    AND (
+        (e."account_id" = t."from_account_id" AND e."amount" = -t."amount")
+        OR (e."account_id" = t."to_account_id" AND e."amount" = t."to_amount")
+    );
@@ -0,0 +1,10 @@
+ALTER TABLE "entries" ADD COLUMN "kind" varchar NOT NULL DEFAULT 'transfer';
+
+-- entries which weren't caused by a transfer could only come from direct balance changes
+UPDATE "entries" SET "kind" = 'adjustment' WHERE "transfer_id" IS NULL;
+
+ALTER TABLE "entries" ADD CONSTRAINT "entry_kind" CHECK ("kind" IN ('transfer', 'deposit', 'withdrawal', 'adjustment'));
+
+ALTER TABLE "entries" ADD CONSTRAINT "transfer_entry_has_transfer" CHECK (("kind" = 'transfer') = ("transfer_id" IS NOT NULL));
+
+COMMENT ON COLUMN "entries"."kind" IS 'transfer, deposit, withdrawal or adjustment made by an admin';
@@ -0,0 +1,5 @@
+drop index if exists "owner_currency_key";
+
+alter table if exists "accounts" add constraint "owner_currency_key" unique ("owner", "currency");
+
+alter table if exists "accounts" drop column if exists "status";
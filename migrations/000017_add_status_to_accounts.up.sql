@@ -0,0 +1,10 @@
+ALTER TABLE "accounts" ADD COLUMN "status" varchar NOT NULL DEFAULT 'active';
+
+ALTER TABLE "accounts" ADD CONSTRAINT "account_status" CHECK ("status" IN ('active', 'frozen', 'closed'));
+
+COMMENT ON COLUMN "accounts"."status" IS 'active, frozen or closed, only active accounts can move money';
+
+-- closed accounts are kept for their history, the owner may open a new account in the same currency
+ALTER TABLE "accounts" DROP CONSTRAINT "owner_currency_key";
+
+CREATE UNIQUE INDEX "owner_currency_key" ON "accounts" ("owner", "currency") WHERE "status" <> 'closed';
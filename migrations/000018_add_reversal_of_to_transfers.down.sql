@@ -0,0 +1 @@
+alter table if exists "transfers" drop column if exists "reversal_of";
@@ -0,0 +1,7 @@
+ALTER TABLE "transfers" ADD COLUMN "reversal_of" bigint;
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("reversal_of") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "transfers" ("reversal_of");
+
+COMMENT ON COLUMN "transfers"."reversal_of" IS 'transfer refunded by this one, a transfer may be refunded in several parts';
@@ -0,0 +1,53 @@
+CREATE TABLE "scheduled_transfers" (
+    "id" bigserial PRIMARY KEY,
+    "owner" varchar NOT NULL,
+    "from_account_id" bigint NOT NULL,
+    "to_account_id" bigint NOT NULL,
+    "amount" bigint NOT NULL,
+    "currency" varchar NOT NULL,
+    "interval_unit" varchar NOT NULL,
+    "interval_count" integer NOT NULL DEFAULT 1,
+    "next_run_at" timestamptz NOT NULL,
+    "end_date" timestamptz,
+    "active" boolean NOT NULL DEFAULT true,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "scheduled_transfer_runs" (
+    "id" bigserial PRIMARY KEY,
+    "scheduled_transfer_id" bigint NOT NULL,
+    "transfer_id" bigint,
+    "error" varchar NOT NULL DEFAULT '',
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+
+ALTER TABLE "scheduled_transfers" ADD CONSTRAINT "scheduled_amount_positive" CHECK ("amount" > 0);
+
+ALTER TABLE "scheduled_transfers" ADD CONSTRAINT "scheduled_interval" CHECK ("interval_unit" IN ('day', 'week', 'month') AND "interval_count" > 0);
+
+ALTER TABLE "scheduled_transfer_runs" ADD FOREIGN KEY ("scheduled_transfer_id") REFERENCES "scheduled_transfers" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "scheduled_transfer_runs" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+-- the worker only looks for due active schedules
+CREATE INDEX ON "scheduled_transfers" ("next_run_at") WHERE "active";
+
+CREATE INDEX ON "scheduled_transfers" ("owner", "id");
+
+CREATE INDEX ON "scheduled_transfer_runs" ("scheduled_transfer_id", "id");
+
+COMMENT ON COLUMN "scheduled_transfers"."interval_unit" IS 'day, week or month, the transfer runs every interval_count units';
+
+COMMENT ON COLUMN "scheduled_transfers"."end_date" IS 'no run is scheduled after it, empty for transfers without an end';
+
+COMMENT ON COLUMN "scheduled_transfer_runs"."transfer_id" IS 'transfer made by the run, empty when the run failed';
+
+COMMENT ON COLUMN "scheduled_transfer_runs"."error" IS 'why the run failed, empty when it succeeded';
@@ -0,0 +1,11 @@
+alter table if exists "entries" drop column if exists "metadata";
+
+alter table if exists "entries" drop column if exists "external_reference";
+
+alter table if exists "entries" drop column if exists "description";
+
+alter table if exists "transfers" drop column if exists "metadata";
+
+alter table if exists "transfers" drop column if exists "external_reference";
+
+alter table if exists "transfers" drop column if exists "description";
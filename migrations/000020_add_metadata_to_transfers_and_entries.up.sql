@@ -0,0 +1,32 @@
+ALTER TABLE "transfers" ADD COLUMN "description" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "transfers" ADD COLUMN "external_reference" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "transfers" ADD COLUMN "metadata" jsonb NOT NULL DEFAULT '{}';
+
+ALTER TABLE "entries" ADD COLUMN "description" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "entries" ADD COLUMN "external_reference" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "entries" ADD COLUMN "metadata" jsonb NOT NULL DEFAULT '{}';
+
+ALTER TABLE "transfers" ADD CONSTRAINT "transfer_metadata_object" CHECK (jsonb_typeof("metadata") = 'object');
+
+ALTER TABLE "entries" ADD CONSTRAINT "entry_metadata_object" CHECK (jsonb_typeof("metadata") = 'object');
+
+CREATE INDEX ON "transfers" ("external_reference") WHERE "external_reference" <> '';
+
+-- transfer listings are searched by metadata containment
+CREATE INDEX ON "transfers" USING GIN ("metadata" jsonb_path_ops);
+
+COMMENT ON COLUMN "transfers"."description" IS 'shown to both parties on their statements';
+
+COMMENT ON COLUMN "transfers"."external_reference" IS 'reference of the transfer in the client system, like an invoice number';
+
+COMMENT ON COLUMN "transfers"."metadata" IS 'arbitrary string values set by the client';
+
+COMMENT ON COLUMN "entries"."description" IS 'copied from the transfer';
+
+COMMENT ON COLUMN "entries"."external_reference" IS 'copied from the transfer';
+
+COMMENT ON COLUMN "entries"."metadata" IS 'copied from the transfer';
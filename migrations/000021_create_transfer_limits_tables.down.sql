@@ -0,0 +1,3 @@
+drop table if exists "user_transfer_limits";
+
+drop table if exists "transfer_limits";
@@ -0,0 +1,51 @@
+CREATE TABLE "transfer_limits" (
+    "currency" varchar(3) PRIMARY KEY,
+    "max_amount" bigint NOT NULL DEFAULT 0,
+    "account_daily_limit" bigint NOT NULL DEFAULT 0,
+    "account_monthly_limit" bigint NOT NULL DEFAULT 0,
+    "user_daily_limit" bigint NOT NULL DEFAULT 0,
+    "user_monthly_limit" bigint NOT NULL DEFAULT 0,
+    "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "user_transfer_limits" (
+    "username" varchar NOT NULL,
+    "currency" varchar(3) NOT NULL,
+    "max_amount" bigint NOT NULL DEFAULT 0,
+    "account_daily_limit" bigint NOT NULL DEFAULT 0,
+    "account_monthly_limit" bigint NOT NULL DEFAULT 0,
+    "user_daily_limit" bigint NOT NULL DEFAULT 0,
+    "user_monthly_limit" bigint NOT NULL DEFAULT 0,
+    "updated_at" timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("username", "currency")
+);
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+
+ALTER TABLE "user_transfer_limits" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "user_transfer_limits" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+
+ALTER TABLE "transfer_limits" ADD CONSTRAINT "transfer_limits_not_negative" CHECK (
+    "max_amount" >= 0 AND "account_daily_limit" >= 0 AND "account_monthly_limit" >= 0
+    AND "user_daily_limit" >= 0 AND "user_monthly_limit" >= 0
+);
+
+ALTER TABLE "user_transfer_limits" ADD CONSTRAINT "user_transfer_limits_not_negative" CHECK (
+    "max_amount" >= 0 AND "account_daily_limit" >= 0 AND "account_monthly_limit" >= 0
+    AND "user_daily_limit" >= 0 AND "user_monthly_limit" >= 0
+);
+
+COMMENT ON TABLE "transfer_limits" IS 'default limits of outgoing transfers per currency, 0 means no limit';
+
+COMMENT ON TABLE "user_transfer_limits" IS 'limits set by an admin for a user, they replace the defaults of the currency';
+
+COMMENT ON COLUMN "transfer_limits"."max_amount" IS 'max amount of a single transfer';
+
+COMMENT ON COLUMN "transfer_limits"."account_daily_limit" IS 'max total sent from one account per UTC day';
+
+COMMENT ON COLUMN "transfer_limits"."account_monthly_limit" IS 'max total sent from one account per UTC month';
+
+COMMENT ON COLUMN "transfer_limits"."user_daily_limit" IS 'max total sent from all accounts of a user in the currency per UTC day';
+
+COMMENT ON COLUMN "transfer_limits"."user_monthly_limit" IS 'max total sent from all accounts of a user in the currency per UTC month';
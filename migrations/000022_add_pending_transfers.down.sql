@@ -0,0 +1,11 @@
+alter table if exists "transfers" drop column if exists "expires_at";
+
+alter table if exists "transfers" drop column if exists "status";
+
+alter table if exists "accounts" drop constraint if exists "balance_within_overdraft_limit";
+
+alter table if exists "accounts" add constraint "balance_within_overdraft_limit" check ("balance" >= -"overdraft_limit");
+
+alter table if exists "accounts" drop column if exists "available_balance";
+
+alter table if exists "accounts" drop column if exists "held_amount";
@@ -0,0 +1,27 @@
+ALTER TABLE "accounts" ADD COLUMN "held_amount" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "accounts" ADD COLUMN "available_balance" bigint GENERATED ALWAYS AS ("balance" - "held_amount") STORED;
+
+ALTER TABLE "accounts" ADD CONSTRAINT "held_amount_not_negative" CHECK ("held_amount" >= 0);
+
+-- held money can't be spent, so the overdraft limit applies to the available balance
+ALTER TABLE "accounts" DROP CONSTRAINT "balance_within_overdraft_limit";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "balance_within_overdraft_limit" CHECK ("balance" - "held_amount" >= -"overdraft_limit");
+
+ALTER TABLE "transfers" ADD COLUMN "status" varchar NOT NULL DEFAULT 'posted';
+
+ALTER TABLE "transfers" ADD COLUMN "expires_at" timestamptz;
+
+ALTER TABLE "transfers" ADD CONSTRAINT "transfer_status" CHECK ("status" IN ('pending', 'posted', 'voided'));
+
+-- the sweeper looks for expired holds only
+CREATE INDEX ON "transfers" ("expires_at") WHERE "status" = 'pending';
+
+COMMENT ON COLUMN "accounts"."held_amount" IS 'sum of the pending transfers from the account';
+
+COMMENT ON COLUMN "accounts"."available_balance" IS 'balance which can be spent, held money excluded';
+
+COMMENT ON COLUMN "transfers"."status" IS 'pending while the money is held, posted once moved or voided when the hold is released';
+
+COMMENT ON COLUMN "transfers"."expires_at" IS 'pending transfers are voided after it';
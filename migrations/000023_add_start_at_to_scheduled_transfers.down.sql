@@ -0,0 +1 @@
+alter table if exists "scheduled_transfers" drop column if exists "start_at";
@@ -0,0 +1,8 @@
+ALTER TABLE "scheduled_transfers" ADD COLUMN "start_at" timestamptz;
+
+-- runs of existing schedules are counted from their next run
+UPDATE "scheduled_transfers" SET "start_at" = "next_run_at";
+
+ALTER TABLE "scheduled_transfers" ALTER COLUMN "start_at" SET NOT NULL;
+
+COMMENT ON COLUMN "scheduled_transfers"."start_at" IS 'first run of the schedule, every run is counted from it so a short month does not shift the next ones';
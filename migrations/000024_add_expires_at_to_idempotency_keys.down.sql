@@ -0,0 +1,3 @@
+drop index if exists "revoked_tokens_expires_at_idx";
+
+alter table if exists "idempotency_keys" drop column if exists "expires_at";
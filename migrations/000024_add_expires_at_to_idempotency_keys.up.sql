@@ -0,0 +1,13 @@
+ALTER TABLE "idempotency_keys" ADD COLUMN "expires_at" timestamptz;
+
+-- existing keys are kept for a day like new ones
+UPDATE "idempotency_keys" SET "expires_at" = "created_at" + INTERVAL '1 day';
+
+ALTER TABLE "idempotency_keys" ALTER COLUMN "expires_at" SET NOT NULL;
+
+-- the sweeper deletes expired keys and revoked tokens
+CREATE INDEX ON "idempotency_keys" ("expires_at");
+
+CREATE INDEX ON "revoked_tokens" ("expires_at");
+
+COMMENT ON COLUMN "idempotency_keys"."expires_at" IS 'the key may be deleted after it, a retry is not expected anymore';
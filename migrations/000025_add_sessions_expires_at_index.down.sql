@@ -0,0 +1 @@
+drop index if exists "sessions_expires_at_idx";
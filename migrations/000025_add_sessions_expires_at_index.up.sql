@@ -0,0 +1,2 @@
+-- the sweeper deletes expired sessions
+CREATE INDEX ON "sessions" ("expires_at");
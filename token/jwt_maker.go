@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 	"time"
 )
 
@@ -21,18 +22,19 @@ func NewJWTMaker(secretKey string) (Maker, error) {
 	return &JWTMaker{secretKey: secretKey}, nil
 }
 
-func (m *JWTMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	payload, err := NewPayload(username, duration)
+func (m *JWTMaker) CreateToken(username, role string, tokenType TokenType, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, tokenType, sessionID, duration)
 	if err != nil {
-		return "", nil
+		return "", nil, err
 	}
 
 	// create new jwt token with claims to provide payload with implemented method Valid which checks expire time
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
 	//finally we generate sighed jwt token by providing secret key
-	return jwtToken.SignedString([]byte(m.secretKey))
+	token, err := jwtToken.SignedString([]byte(m.secretKey))
+	return token, payload, err
 }
-func (m *JWTMaker) VerifyToken(token string) (*Payload, error) {
+func (m *JWTMaker) VerifyToken(token string, tokenType TokenType) (*Payload, error) {
 	//First, we should parse a token:
 	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, func(token *jwt.Token) (interface{}, error) {
 		// token.Method is an interface, and cryptic algorithms use it interface, so in that way below we specify which algorithm we've used.
@@ -54,7 +56,7 @@ func (m *JWTMaker) VerifyToken(token string) (*Payload, error) {
 	//if parse went successfully we can get payload by convert claims into payload object
 	// if object satisfies Claims interface we can cast to our payload
 	payload, ok := jwtToken.Claims.(*Payload)
-	if !ok {
+	if !ok || payload.Type != tokenType {
 		return nil, ErrInvalidToken
 	}
 
@@ -2,6 +2,7 @@ package token
 
 import (
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 	"github.com/max-rodziyevsky/go-simple-bank/util"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -13,21 +14,28 @@ func TestNewJWTMaker(t *testing.T) {
 	require.NoError(t, err)
 
 	username := util.RandomOwner()
+	role := util.DepositorRole
+	sessionID := uuid.New()
 	duration := time.Minute
 
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(duration)
 
-	token, err := maker.CreateToken(username, duration)
+	token, createdPayload, err := maker.CreateToken(username, role, AccessToken, sessionID, duration)
 	require.NoError(t, err)
+	require.NotEmpty(t, createdPayload)
 	require.NotEmpty(t, token)
 
-	payload, err := maker.VerifyToken(token)
+	payload, err := maker.VerifyToken(token, AccessToken)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
 
 	require.NotZero(t, payload.ID)
+	require.Equal(t, createdPayload.ID, payload.ID)
 	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
+	require.Equal(t, AccessToken, payload.Type)
+	require.Equal(t, sessionID, payload.SessionID)
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
 }
@@ -36,18 +44,19 @@ func TestExpiredJWTToken(t *testing.T) {
 	maker, err := NewJWTMaker(util.RandomString(32))
 	require.NoError(t, err)
 
-	token, err := maker.CreateToken(util.RandomOwner(), -time.Minute)
+	token, createdPayload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, AccessToken, uuid.New(), -time.Minute)
 	require.NoError(t, err)
+	require.NotEmpty(t, createdPayload)
 	require.NotEmpty(t, token)
 
-	payload, err := maker.VerifyToken(token)
+	payload, err := maker.VerifyToken(token, AccessToken)
 	require.Error(t, err)
 	require.EqualError(t, err, ErrExpiredToken.Error())
 	require.Nil(t, payload)
 }
 
 func TestInvalidJWTTokenAlgNone(t *testing.T) {
-	payload, err := NewPayload(util.RandomOwner(), time.Minute)
+	payload, err := NewPayload(util.RandomOwner(), util.DepositorRole, AccessToken, uuid.New(), time.Minute)
 	require.NoError(t, err)
 
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodNone, payload)
@@ -58,8 +67,24 @@ func TestInvalidJWTTokenAlgNone(t *testing.T) {
 	maker, err := NewJWTMaker(util.RandomString(32))
 	require.NoError(t, err)
 
-	payload, err = maker.VerifyToken(token)
+	payload, err = maker.VerifyToken(token, AccessToken)
 	require.Error(t, err)
 	require.EqualError(t, err, ErrInvalidToken.Error())
 	require.Nil(t, payload)
 }
+
+func TestWrongTypeJWTToken(t *testing.T) {
+	maker, err := NewJWTMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, RefreshToken, uuid.Nil, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token, AccessToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+
+	payload, err = maker.VerifyToken(token, RefreshToken)
+	require.NoError(t, err)
+	require.Equal(t, RefreshToken, payload.Type)
+}
@@ -1,11 +1,31 @@
 package token
 
 import (
+	"fmt"
+	"github.com/google/uuid"
 	"time"
 )
 
+const (
+	PasetoType = "paseto"
+	JWTType    = "jwt"
+)
+
 // Maker is an interface for managing tokens
 type Maker interface {
-	CreateToken(username string, duration time.Duration) (string, error)
-	VerifyToken(token string) (*Payload, error)
+	CreateToken(username, role string, tokenType TokenType, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error)
+	// VerifyToken returns ErrInvalidToken for a token of another type, so a refresh token can't be used as an access token
+	VerifyToken(token string, tokenType TokenType) (*Payload, error)
+}
+
+// NewMaker creates a token maker of the given type (paseto or jwt)
+func NewMaker(tokenType, symmetricKey string) (Maker, error) {
+	switch tokenType {
+	case PasetoType:
+		return NewPasetoMaker(symmetricKey)
+	case JWTType:
+		return NewJWTMaker(symmetricKey)
+	}
+
+	return nil, fmt.Errorf("unsupported token type: %s", tokenType)
 }
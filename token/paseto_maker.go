@@ -2,6 +2,7 @@ package token
 
 import (
 	"fmt"
+	"github.com/google/uuid"
 	"github.com/o1egl/paseto"
 	"golang.org/x/crypto/chacha20poly1305"
 	"time"
@@ -25,15 +26,16 @@ func NewPasetoMaker(symmetricKey string) (Maker, error) {
 	return maker, nil
 }
 
-func (m *PasetoMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	payload, err := NewPayload(username, duration)
+func (m *PasetoMaker) CreateToken(username, role string, tokenType TokenType, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, tokenType, sessionID, duration)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	return m.paseto.Encrypt(m.symmetricKey, payload, nil)
+	token, err := m.paseto.Encrypt(m.symmetricKey, payload, nil)
+	return token, payload, err
 }
-func (m *PasetoMaker) VerifyToken(token string) (*Payload, error) {
+func (m *PasetoMaker) VerifyToken(token string, tokenType TokenType) (*Payload, error) {
 	payload := &Payload{}
 
 	if err := m.paseto.Decrypt(token, m.symmetricKey, payload, nil); err != nil {
@@ -44,5 +46,9 @@ func (m *PasetoMaker) VerifyToken(token string) (*Payload, error) {
 		return nil, err
 	}
 
+	if payload.Type != tokenType {
+		return nil, ErrInvalidToken
+	}
+
 	return payload, nil
 }
@@ -1,6 +1,7 @@
 package token
 
 import (
+	"github.com/google/uuid"
 	"github.com/max-rodziyevsky/go-simple-bank/util"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -13,23 +14,30 @@ func TestNewPasetoMaker(t *testing.T) {
 	require.NotNil(t, maker)
 
 	username := util.RandomOwner()
+	role := util.DepositorRole
+	sessionID := uuid.New()
 	duration := time.Minute
 
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(time.Minute)
 
 	//test create
-	token, err := maker.CreateToken(username, duration)
+	token, createdPayload, err := maker.CreateToken(username, role, AccessToken, sessionID, duration)
 	require.NoError(t, err)
+	require.NotEmpty(t, createdPayload)
 	require.NotNil(t, token)
 
 	//test verify
-	payload, err := maker.VerifyToken(token)
+	payload, err := maker.VerifyToken(token, AccessToken)
 	require.NoError(t, err)
 	require.NotNil(t, payload)
 
 	require.NotNil(t, payload.ID)
+	require.Equal(t, createdPayload.ID, payload.ID)
 	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
+	require.Equal(t, AccessToken, payload.Type)
+	require.Equal(t, sessionID, payload.SessionID)
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
 }
@@ -38,12 +46,29 @@ func TestExpiredPasetoToken(t *testing.T) {
 	maker, err := NewPasetoMaker(util.RandomString(32))
 	require.NoError(t, err)
 
-	token, err := maker.CreateToken(util.RandomOwner(), -time.Minute)
+	token, createdPayload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, AccessToken, uuid.New(), -time.Minute)
 	require.NoError(t, err)
+	require.NotEmpty(t, createdPayload)
 	require.NotEmpty(t, token)
 
-	payload, err := maker.VerifyToken(token)
+	payload, err := maker.VerifyToken(token, AccessToken)
 	require.Error(t, err)
 	require.EqualError(t, err, ErrExpiredToken.Error())
 	require.Nil(t, payload)
 }
+
+func TestWrongTypePasetoToken(t *testing.T) {
+	maker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, RefreshToken, uuid.Nil, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token, AccessToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+
+	payload, err = maker.VerifyToken(token, RefreshToken)
+	require.NoError(t, err)
+	require.Equal(t, RefreshToken, payload.Type)
+}
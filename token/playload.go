@@ -11,14 +11,27 @@ var (
 	ErrInvalidToken = errors.New("invalid token")
 )
 
+// TokenType tells what a token may be used for, a refresh token only renews access tokens
+type TokenType string
+
+const (
+	AccessToken  TokenType = "access"
+	RefreshToken TokenType = "refresh"
+)
+
 type Payload struct {
-	ID        uuid.UUID `json:"id"`
+	ID   uuid.UUID `json:"id"`
+	Type TokenType `json:"token_type"`
+	// SessionID is the session an access token was issued for, the id of the refresh token of the login.
+	// It's empty in refresh tokens, they identify the session themselves.
+	SessionID uuid.UUID `json:"session_id"`
 	Username  string    `json:"username"`
+	Role      string    `json:"role"`
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expired_at"`
 }
 
-func NewPayload(username string, duration time.Duration) (*Payload, error) {
+func NewPayload(username, role string, tokenType TokenType, sessionID uuid.UUID, duration time.Duration) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
@@ -26,7 +39,10 @@ func NewPayload(username string, duration time.Duration) (*Payload, error) {
 
 	payload := &Payload{
 		ID:        tokenID,
+		Type:      tokenType,
+		SessionID: sessionID,
 		Username:  username,
+		Role:      role,
 		IssuedAt:  time.Now(),
 		ExpiredAt: time.Now().Add(duration),
 	}
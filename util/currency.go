@@ -1,15 +1,8 @@
 package util
 
+// Currencies seeded by migrations, the list of supported currencies is kept in the currencies table
 const (
 	USD = "USD"
 	EUR = "EUR"
 	UAH = "UAH"
 )
-
-func IsSupportedCurrency(currency string) bool {
-	switch currency {
-	case EUR, USD, UAH:
-		return true
-	}
-	return false
-}